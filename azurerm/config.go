@@ -84,10 +84,12 @@ type ArmClient struct {
 	dnsClient   dns.RecordSetsClient
 	zonesClient dns.ZonesClient
 
-	containerRegistryClient  containerregistry.RegistriesClient
-	containerServicesClient  containerservice.ContainerServicesClient
-	kubernetesClustersClient containerservice.ManagedClustersClient
-	containerGroupsClient    containerinstance.ContainerGroupsClient
+	containerRegistryClient   containerregistry.RegistriesClient
+	containerServicesClient   containerservice.ContainerServicesClient
+	kubernetesClustersClient  containerservice.ManagedClustersClient
+	containerGroupsClient     containerinstance.ContainerGroupsClient
+	containerGroupUsageClient containerinstance.ContainerGroupUsageClient
+	containerLogsClient       containerinstance.ContainerLogsClient
 
 	eventGridTopicsClient       eventgrid.TopicsClient
 	eventHubClient              eventhub.EventHubsClient
@@ -577,6 +579,14 @@ func (c *ArmClient) registerContainerInstanceClients(endpoint, subscriptionId st
 	cgc := containerinstance.NewContainerGroupsClientWithBaseURI(endpoint, subscriptionId)
 	c.configureClient(&cgc.Client, auth)
 	c.containerGroupsClient = cgc
+
+	cguc := containerinstance.NewContainerGroupUsageClientWithBaseURI(endpoint, subscriptionId)
+	c.configureClient(&cguc.Client, auth)
+	c.containerGroupUsageClient = cguc
+
+	clc := containerinstance.NewContainerLogsClientWithBaseURI(endpoint, subscriptionId)
+	c.configureClient(&clc.Client, auth)
+	c.containerLogsClient = clc
 }
 
 func (c *ArmClient) registerContainerRegistryClients(endpoint, subscriptionId string, auth autorest.Authorizer, sender autorest.Sender) {
@@ -0,0 +1,303 @@
+package azurerm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// containerImageValidationConcurrency bounds the number of simultaneous registry connections
+// opened when validating the images used by a Container Group
+const containerImageValidationConcurrency = 4
+
+const dockerHubRegistry = "docker.io"
+
+type containerImageReference struct {
+	Registry   string
+	Repository string
+	Reference  string
+}
+
+type containerRegistryCredential struct {
+	Username string
+	Password string
+}
+
+// parseContainerImageReference splits an image (e.g. `microsoft/aci-helloworld:latest` or
+// `myregistry.azurecr.io/app@sha256:...`) into the Registry, Repository and Tag/Digest
+func parseContainerImageReference(image string) (*containerImageReference, error) {
+	if image == "" || strings.TrimSpace(image) != image {
+		return nil, fmt.Errorf("%q is not a valid image reference", image)
+	}
+
+	ref := containerImageReference{
+		Registry:  dockerHubRegistry,
+		Reference: "latest",
+	}
+
+	name := image
+	if i := strings.Index(name, "@"); i != -1 {
+		ref.Reference = name[i+1:]
+		name = name[:i]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Reference = name[i+1:]
+		name = name[:i]
+	}
+
+	// the first component is only a registry if it looks like a host
+	if i := strings.Index(name, "/"); i != -1 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = normalizeContainerRegistryServer(host)
+			name = name[i+1:]
+		}
+	}
+
+	if ref.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	if name == "" || ref.Reference == "" {
+		return nil, fmt.Errorf("%q is not a valid image reference", image)
+	}
+
+	ref.Repository = name
+	return &ref, nil
+}
+
+func normalizeContainerRegistryServer(server string) string {
+	server = strings.ToLower(server)
+	switch server {
+	case "hub.docker.com", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHubRegistry
+	}
+	return server
+}
+
+// validateContainerImages checks that each of the images can be pulled from its registry, using
+// at most `containerImageValidationConcurrency` connections at once
+func validateContainerImages(ctx context.Context, client *http.Client, images []string, credentials map[string]containerRegistryCredential) error {
+	unique := make([]string, 0, len(images))
+	seen := make(map[string]bool)
+	for _, image := range images {
+		if !seen[image] {
+			seen[image] = true
+			unique = append(unique, image)
+		}
+	}
+
+	errs := make([]error, len(unique))
+	semaphore := make(chan struct{}, containerImageValidationConcurrency)
+	var wg sync.WaitGroup
+
+	for i, image := range unique {
+		wg.Add(1)
+		go func(i int, image string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			errs[i] = checkContainerImagePullable(ctx, client, image, credentials)
+		}(i, image)
+	}
+	wg.Wait()
+
+	// report the failures in the order the containers were defined, so the error is stable
+	messages := make([]string, 0)
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("Error validating the Container Group images:\n\n%s", strings.Join(messages, "\n"))
+	}
+
+	return nil
+}
+
+func checkContainerImagePullable(ctx context.Context, client *http.Client, image string, credentials map[string]containerRegistryCredential) error {
+	ref, err := parseContainerImageReference(image)
+	if err != nil {
+		return err
+	}
+
+	var cred *containerRegistryCredential
+	if c, ok := credentials[ref.Registry]; ok {
+		cred = &c
+	}
+
+	host := ref.Registry
+	if host == dockerHubRegistry {
+		host = "registry-1.docker.io"
+	}
+	manifestUrl := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, ref.Repository, ref.Reference)
+
+	resp, err := headContainerImageManifest(ctx, client, manifestUrl, cred, "")
+	if err != nil {
+		return fmt.Errorf("Image %q could not be validated: %+v", image, err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+			token, err := fetchContainerRegistryToken(ctx, client, challenge, cred)
+			if err != nil {
+				return fmt.Errorf("Image %q could not be validated: %+v", image, err)
+			}
+			resp, err = headContainerImageManifest(ctx, client, manifestUrl, nil, token)
+			if err != nil {
+				return fmt.Errorf("Image %q could not be validated: %+v", image, err)
+			}
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("Image %q was not found in the registry %q", image, ref.Registry)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if cred == nil {
+			return fmt.Errorf("Image %q cannot be pulled from the registry %q without credentials - add an `image_registry_credential` block for this server", image, ref.Registry)
+		}
+		return fmt.Errorf("Image %q cannot be pulled from the registry %q using the configured `image_registry_credential`", image, ref.Registry)
+	}
+
+	return fmt.Errorf("Image %q could not be validated: unexpected status %d from the registry %q", image, resp.StatusCode, ref.Registry)
+}
+
+func headContainerImageManifest(ctx context.Context, client *http.Client, manifestUrl string, cred *containerRegistryCredential, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Accept", "application/vnd.docker.distribution.manifest.v2+json")
+	req.Header.Add("Accept", "application/vnd.docker.distribution.manifest.list.v2+json")
+	req.Header.Add("Accept", "application/vnd.oci.image.manifest.v1+json")
+	req.Header.Add("Accept", "application/vnd.oci.image.index.v1+json")
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if cred != nil {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+// fetchContainerRegistryToken obtains a pull token for the scope requested in a Bearer challenge
+func fetchContainerRegistryToken(ctx context.Context, client *http.Client, challenge string, cred *containerRegistryCredential) (string, error) {
+	params := parseContainerRegistryChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("the registry returned an authentication challenge without a realm")
+	}
+
+	tokenUrl, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("the registry returned an invalid authentication realm %q: %+v", realm, err)
+	}
+	query := tokenUrl.Query()
+	for _, key := range []string{"service", "scope"} {
+		if v := params[key]; v != "" {
+			query.Set(key, v)
+		}
+	}
+	tokenUrl.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, tokenUrl.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	if cred != nil {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// an unauthorized token request is surfaced as an unauthorized manifest request
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d retrieving a token from %q", resp.StatusCode, realm)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("Error decoding the token from %q: %+v", realm, err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseContainerRegistryChallenge parses the key/value pairs from a `WWW-Authenticate: Bearer ...` header
+func parseContainerRegistryChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+
+	if i := strings.Index(challenge, " "); i != -1 {
+		challenge = challenge[i+1:]
+	}
+
+	for challenge != "" {
+		eq := strings.Index(challenge, "=")
+		if eq == -1 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(challenge[:eq]))
+		challenge = challenge[eq+1:]
+
+		var value string
+		if strings.HasPrefix(challenge, `"`) {
+			end := strings.Index(challenge[1:], `"`)
+			if end == -1 {
+				value = challenge[1:]
+				challenge = ""
+			} else {
+				value = challenge[1 : end+1]
+				challenge = challenge[end+2:]
+			}
+		} else if comma := strings.Index(challenge, ","); comma != -1 {
+			value = challenge[:comma]
+			challenge = challenge[comma:]
+		} else {
+			value = challenge
+			challenge = ""
+		}
+
+		params[key] = value
+		challenge = strings.TrimLeft(challenge, ", ")
+	}
+
+	return params
+}
+
+func newContainerImageValidationClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+	}
+}
@@ -0,0 +1,174 @@
+package azurerm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAzureRMContainerImageReference_parse(t *testing.T) {
+	cases := []struct {
+		Image    string
+		Expected *containerImageReference
+	}{
+		{
+			Image:    "nginx",
+			Expected: &containerImageReference{Registry: "docker.io", Repository: "library/nginx", Reference: "latest"},
+		},
+		{
+			Image:    "microsoft/aci-helloworld:v1",
+			Expected: &containerImageReference{Registry: "docker.io", Repository: "microsoft/aci-helloworld", Reference: "v1"},
+		},
+		{
+			Image:    "index.docker.io/nginx:1.15",
+			Expected: &containerImageReference{Registry: "docker.io", Repository: "library/nginx", Reference: "1.15"},
+		},
+		{
+			Image:    "myregistry.azurecr.io/team/app:2.0",
+			Expected: &containerImageReference{Registry: "myregistry.azurecr.io", Repository: "team/app", Reference: "2.0"},
+		},
+		{
+			Image:    "localhost:5000/app",
+			Expected: &containerImageReference{Registry: "localhost:5000", Repository: "app", Reference: "latest"},
+		},
+		{
+			Image:    "localhost/app@sha256:abc123",
+			Expected: &containerImageReference{Registry: "localhost", Repository: "app", Reference: "sha256:abc123"},
+		},
+		{
+			Image: "",
+		},
+		{
+			Image: "nginx:",
+		},
+		{
+			Image: " nginx",
+		},
+	}
+
+	for _, tc := range cases {
+		actual, err := parseContainerImageReference(tc.Image)
+		if tc.Expected == nil {
+			if err == nil {
+				t.Fatalf("Expected %q to fail parsing but got %+v", tc.Image, actual)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Expected %q to parse but got: %+v", tc.Image, err)
+		}
+		if *actual != *tc.Expected {
+			t.Fatalf("Expected %q to parse as %+v but got %+v", tc.Image, *tc.Expected, *actual)
+		}
+	}
+}
+
+func TestAzureRMContainerRegistryServer_normalize(t *testing.T) {
+	cases := map[string]string{
+		"hub.docker.com":          "docker.io",
+		"index.docker.io":         "docker.io",
+		"registry-1.docker.io":    "docker.io",
+		"registry.hub.docker.com": "docker.io",
+		"Hub.Docker.com":          "docker.io",
+		"myregistry.azurecr.io":   "myregistry.azurecr.io",
+		"MyRegistry.azurecr.io":   "myregistry.azurecr.io",
+	}
+
+	for server, expected := range cases {
+		if actual := normalizeContainerRegistryServer(server); actual != expected {
+			t.Fatalf("Expected %q to normalize to %q but got %q", server, expected, actual)
+		}
+	}
+}
+
+func TestAzureRMContainerImageRegistryChallenge_parse(t *testing.T) {
+	challenge := `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`
+	actual := parseContainerRegistryChallenge(challenge)
+
+	expected := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/nginx:pull",
+	}
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Fatalf("Expected %q to be %q but got %q", k, v, actual[k])
+		}
+	}
+}
+
+func TestAzureRMContainerImages_validate(t *testing.T) {
+	var lock sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"pull-token"}`))
+			return
+		}
+
+		lock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		lock.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		lock.Lock()
+		inFlight--
+		lock.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/v2/missing/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://")
+	credentials := map[string]containerRegistryCredential{
+		registry: {Username: "admin", Password: "secret"},
+	}
+
+	images := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		images = append(images, registry+"/app"+string('a'+rune(i))+":latest")
+	}
+
+	if err := validateContainerImages(context.Background(), server.Client(), images, credentials); err != nil {
+		t.Fatalf("Expected the images to be valid but got: %+v", err)
+	}
+	if maxInFlight > containerImageValidationConcurrency {
+		t.Fatalf("Expected at most %d concurrent requests but got %d", containerImageValidationConcurrency, maxInFlight)
+	}
+
+	err := validateContainerImages(context.Background(), server.Client(), append(images, registry+"/missing:1.0"), credentials)
+	if err == nil || !strings.Contains(err.Error(), registry+"/missing:1.0") || !strings.Contains(err.Error(), "was not found") {
+		t.Fatalf("Expected the missing image to be reported but got: %+v", err)
+	}
+
+	err = validateContainerImages(context.Background(), server.Client(), images[:1], nil)
+	if err == nil || !strings.Contains(err.Error(), "without credentials") {
+		t.Fatalf("Expected the image to require credentials but got: %+v", err)
+	}
+}
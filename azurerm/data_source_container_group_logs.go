@@ -0,0 +1,100 @@
+package azurerm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/utils"
+)
+
+func dataSourceArmContainerGroupLogs() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceArmContainerGroupLogsRead,
+
+		Schema: map[string]*schema.Schema{
+			"container_group_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"resource_group_name": resourceGroupNameForDataSourceSchema(),
+
+			"container_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"tail": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+
+			"content": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"line_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceArmContainerGroupLogsRead(d *schema.ResourceData, meta interface{}) error {
+	groupsClient := meta.(*ArmClient).containerGroupsClient
+	logsClient := meta.(*ArmClient).containerLogsClient
+	ctx := meta.(*ArmClient).StopContext
+
+	containerGroupName := d.Get("container_group_name").(string)
+	resourceGroup := d.Get("resource_group_name").(string)
+	containerName := d.Get("container_name").(string)
+
+	group, err := groupsClient.Get(ctx, resourceGroup, containerGroupName)
+	if err != nil {
+		if utils.ResponseWasNotFound(group.Response) {
+			return fmt.Errorf("Error: Container Group %q was not found in Resource Group %q", containerGroupName, resourceGroup)
+		}
+
+		return fmt.Errorf("Error retrieving Container Group %q (Resource Group %q): %+v", containerGroupName, resourceGroup, err)
+	}
+
+	// the full log buffer is returned when the tail isn't specified
+	var tail *int32
+	if v, ok := d.GetOk("tail"); ok {
+		tail = utils.Int32(int32(v.(int)))
+	}
+
+	resp, err := logsClient.List(ctx, resourceGroup, containerGroupName, containerName, tail)
+	if err != nil {
+		if utils.ResponseWasNotFound(resp.Response) {
+			return fmt.Errorf("Error: Container %q was not found in Container Group %q (Resource Group %q)", containerName, containerGroupName, resourceGroup)
+		}
+
+		return fmt.Errorf("Error retrieving the logs of Container %q in Container Group %q (Resource Group %q): %+v", containerName, containerGroupName, resourceGroup, err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/containers/%s/logs", *group.ID, containerName))
+
+	content := ""
+	if resp.Content != nil {
+		content = *resp.Content
+	}
+	d.Set("content", content)
+	d.Set("line_count", containerLogsLineCount(content))
+
+	return nil
+}
+
+func containerLogsLineCount(content string) int {
+	if content == "" {
+		return 0
+	}
+
+	// a trailing newline terminates the last line, rather than starting a new one
+	return len(strings.Split(strings.TrimSuffix(content, "\n"), "\n"))
+}
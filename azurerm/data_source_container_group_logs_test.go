@@ -0,0 +1,64 @@
+package azurerm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAzureRMContainerGroupLogsLineCount(t *testing.T) {
+	cases := []struct {
+		Content  string
+		Expected int
+	}{
+		{Content: "", Expected: 0},
+		{Content: "listening on port 80", Expected: 1},
+		{Content: "listening on port 80\n", Expected: 1},
+		{Content: "listening on port 80\nGET / 200\n", Expected: 2},
+		{Content: "listening on port 80\n\nGET / 200", Expected: 3},
+	}
+
+	for _, tc := range cases {
+		if actual := containerLogsLineCount(tc.Content); actual != tc.Expected {
+			t.Fatalf("Expected %d lines for %q but got %d", tc.Expected, tc.Content, actual)
+		}
+	}
+}
+
+func TestAccDataSourceAzureRMContainerGroupLogs_basic(t *testing.T) {
+	ri := acctest.RandInt()
+	config := testAccDataSourceAzureRMContainerGroupLogs_basic(ri, testLocation())
+
+	dataSourceName := "data.azurerm_container_group_logs.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(dataSourceName, "content"),
+					resource.TestCheckResourceAttrSet(dataSourceName, "line_count"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceAzureRMContainerGroupLogs_basic(rInt int, location string) string {
+	resource := testAccAzureRMContainerGroup_linuxBasic(rInt, location)
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_container_group_logs" "test" {
+  container_group_name = "${azurerm_container_group.test.name}"
+  resource_group_name  = "${azurerm_container_group.test.resource_group_name}"
+  container_name       = "hw"
+  tail                 = 10
+}
+`, resource)
+}
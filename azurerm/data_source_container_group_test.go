@@ -0,0 +1,47 @@
+package azurerm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccDataSourceAzureRMContainerGroup_basic(t *testing.T) {
+	ri := acctest.RandInt()
+	config := testAccDataSourceAzureRMContainerGroup_basic(ri, testLocation())
+
+	dataSourceName := "data.azurerm_container_group.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(dataSourceName, "location"),
+					resource.TestCheckResourceAttrSet(dataSourceName, "ip_address"),
+					resource.TestCheckResourceAttr(dataSourceName, "ip_address_type", "Public"),
+					resource.TestCheckResourceAttr(dataSourceName, "os_type", "Linux"),
+					resource.TestCheckResourceAttr(dataSourceName, "tags.%", "1"),
+					resource.TestCheckResourceAttr(dataSourceName, "tags.environment", "Testing"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceAzureRMContainerGroup_basic(rInt int, location string) string {
+	resource := testAccAzureRMContainerGroup_linuxBasic(rInt, location)
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_container_group" "test" {
+  name                = "${azurerm_container_group.test.name}"
+  resource_group_name = "${azurerm_container_group.test.resource_group_name}"
+}
+`, resource)
+}
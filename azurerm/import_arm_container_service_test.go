@@ -0,0 +1,66 @@
+package azurerm
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccAzureRMContainerService_importDcosBasic(t *testing.T) {
+	resourceName := "azurerm_container_service.test"
+
+	ri := acctest.RandInt()
+	config := testAccAzureRMContainerService_dcosBasic(ri, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccAzureRMContainerService_importKubernetesBasic(t *testing.T) {
+	resourceName := "azurerm_container_service.test"
+
+	ri := acctest.RandInt()
+	clientId := os.Getenv("ARM_CLIENT_ID")
+	clientSecret := os.Getenv("ARM_CLIENT_SECRET")
+	config := testAccAzureRMContainerService_kubernetesBasic(ri, clientId, clientSecret, testLocation())
+
+	// the client secret isn't returned from the API, so it can't be verified
+	servicePrincipalHash := resourceAzureRMContainerServiceServicePrincipalProfileHash(map[string]interface{}{
+		"client_id": clientId,
+	})
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{fmt.Sprintf("service_principal.%d.client_secret", servicePrincipalHash)},
+			},
+		},
+	})
+}
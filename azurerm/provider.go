@@ -86,6 +86,8 @@ func Provider() terraform.ResourceProvider {
 			"azurerm_builtin_role_definition":               dataSourceArmBuiltInRoleDefinition(),
 			"azurerm_cdn_profile":                           dataSourceArmCdnProfile(),
 			"azurerm_client_config":                         dataSourceArmClientConfig(),
+			"azurerm_container_group":                       dataSourceArmContainerGroup(),
+			"azurerm_container_group_logs":                  dataSourceArmContainerGroupLogs(),
 			"azurerm_cosmosdb_account":                      dataSourceArmCosmosDBAccount(),
 			"azurerm_container_registry":                    dataSourceArmContainerRegistry(),
 			"azurerm_data_lake_store":                       dataSourceArmDataLakeStoreAccount(),
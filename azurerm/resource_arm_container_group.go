@@ -1,13 +1,26 @@
 package azurerm
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerinstance/mgmt/2018-04-01/containerinstance"
+	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/azure"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/utils"
 )
 
@@ -15,11 +28,50 @@ func resourceArmContainerGroup() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceArmContainerGroupCreate,
 		Read:   resourceArmContainerGroupRead,
+		Update: resourceArmContainerGroupUpdate,
 		Delete: resourceArmContainerGroupDelete,
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
+
+		CustomizeDiff: func(diff *schema.ResourceDiff, v interface{}) error {
+			if err := validateContainerGroupMountPaths(diff.Get("container").([]interface{})); err != nil {
+				return err
+			}
+
+			if err := validateContainerGroupResourceLimits(diff.Get("container").([]interface{})); err != nil {
+				return err
+			}
+
+			if err := validateContainerGroupVolumeSources(diff.Get("volume").([]interface{}), diff.Get("container").([]interface{})); err != nil {
+				return err
+			}
+
+			// `exposed_port` is computed when it's omitted, so the ports in state are only checked against
+			// the containers once they've come from the config (when creating, or when they've been changed)
+			if diff.Id() == "" || diff.HasChange("exposed_port") {
+				if err := validateContainerGroupExposedPorts(diff.Get("exposed_port").(*schema.Set).List(), diff.Get("container").([]interface{})); err != nil {
+					return err
+				}
+			}
+
+			// changing the FQDN of an existing Container Group has to be opted into, since it breaks any DNS records pointing at it
+			if diff.Id() == "" || !(diff.HasChange("dns_name_label") || diff.HasChange("location")) {
+				return nil
+			}
+
+			oldLabel, newLabel := diff.GetChange("dns_name_label")
+			oldLocation, newLocation := diff.GetChange("location")
+			allowFqdnChange := diff.Get("allow_fqdn_change").(bool)
+			return validateContainerGroupFqdnChange(oldLabel.(string), newLabel.(string), oldLocation.(string), newLocation.(string), allowFqdnChange)
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -56,21 +108,18 @@ func resourceArmContainerGroup() *schema.Resource {
 			"image_registry_credential": {
 				Type:     schema.TypeList,
 				Optional: true,
-				ForceNew: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"server": {
 							Type:         schema.TypeString,
 							Required:     true,
 							ValidateFunc: validation.NoZeroValues,
-							ForceNew:     true,
 						},
 
 						"username": {
 							Type:         schema.TypeString,
 							Required:     true,
 							ValidateFunc: validation.NoZeroValues,
-							ForceNew:     true,
 						},
 
 						"password": {
@@ -78,13 +127,12 @@ func resourceArmContainerGroup() *schema.Resource {
 							Required:     true,
 							Sensitive:    true,
 							ValidateFunc: validation.NoZeroValues,
-							ForceNew:     true,
 						},
 					},
 				},
 			},
 
-			"tags": tagsForceNewSchema(),
+			"tags": tagsSchema(),
 
 			"restart_policy": {
 				Type:             schema.TypeString,
@@ -109,12 +157,212 @@ func resourceArmContainerGroup() *schema.Resource {
 				Computed: true,
 			},
 
+			"fqdn_region": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"compose_yaml": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"exposed_port": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				ForceNew: true,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"port": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.IntBetween(1, 65535),
+						},
+
+						"protocol": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							ForceNew:         true,
+							Default:          string(containerinstance.TCP),
+							DiffSuppressFunc: ignoreCaseDiffSuppressFunc,
+							ValidateFunc: validation.StringInSlice([]string{
+								string(containerinstance.TCP),
+								string(containerinstance.UDP),
+							}, true),
+						},
+					},
+				},
+				Set: resourceArmContainerGroupExposedPortHash,
+			},
+
+			"exposed_ports": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"port": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"protocol": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"endpoints": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"port": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"protocol": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"endpoint": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
 			"dns_name_label": {
 				Type:     schema.TypeString,
 				Optional: true,
 				ForceNew: true,
 			},
 
+			"allow_fqdn_change": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"validate_images": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"is_terminal": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"events": containerGroupEventsSchema(),
+
+			"volume": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+
+						"empty_dir": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							ForceNew: true,
+							Default:  false,
+						},
+
+						"secret": {
+							Type:      schema.TypeMap,
+							Optional:  true,
+							ForceNew:  true,
+							Sensitive: true,
+						},
+
+						"git_repo": {
+							Type:     schema.TypeList,
+							Optional: true,
+							ForceNew: true,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"url": {
+										Type:         schema.TypeString,
+										Required:     true,
+										ForceNew:     true,
+										ValidateFunc: validation.NoZeroValues,
+									},
+
+									"directory": {
+										Type:     schema.TypeString,
+										Optional: true,
+										ForceNew: true,
+									},
+
+									"revision": {
+										Type:     schema.TypeString,
+										Optional: true,
+										ForceNew: true,
+									},
+								},
+							},
+						},
+
+						"share_name": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.NoZeroValues,
+						},
+
+						"storage_account_name": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Computed:     true,
+							ForceNew:     true,
+							ValidateFunc: validateArmStorageAccountName,
+						},
+
+						"storage_account_id": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							ValidateFunc: azure.ValidateResourceID,
+						},
+
+						"storage_account_key": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Sensitive:    true,
+							ForceNew:     true,
+							ValidateFunc: validation.NoZeroValues,
+						},
+
+						"storage_account_key_version": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.NoZeroValues,
+						},
+					},
+				},
+			},
+
 			"container": {
 				Type:     schema.TypeList,
 				Required: true,
@@ -145,10 +393,24 @@ func resourceArmContainerGroup() *schema.Resource {
 							ForceNew: true,
 						},
 
+						"cpu_limit": {
+							Type:     schema.TypeFloat,
+							Optional: true,
+							ForceNew: true,
+						},
+
+						"memory_limit": {
+							Type:     schema.TypeFloat,
+							Optional: true,
+							ForceNew: true,
+						},
+
 						"port": {
 							Type:         schema.TypeInt,
 							Optional:     true,
 							ForceNew:     true,
+							Computed:     true,
+							Deprecated:   "Use `ports` instead.",
 							ValidateFunc: validation.IntBetween(1, 65535),
 						},
 
@@ -156,6 +418,8 @@ func resourceArmContainerGroup() *schema.Resource {
 							Type:             schema.TypeString,
 							Optional:         true,
 							ForceNew:         true,
+							Computed:         true,
+							Deprecated:       "Use `ports` instead.",
 							DiffSuppressFunc: ignoreCaseDiffSuppressFunc,
 							ValidateFunc: validation.StringInSlice([]string{
 								"tcp",
@@ -163,10 +427,40 @@ func resourceArmContainerGroup() *schema.Resource {
 							}, true),
 						},
 
-						"environment_variables": {
-							Type:     schema.TypeMap,
+						"ports": {
+							Type:     schema.TypeList,
 							Optional: true,
 							ForceNew: true,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"port": {
+										Type:         schema.TypeInt,
+										Required:     true,
+										ForceNew:     true,
+										ValidateFunc: validation.IntBetween(1, 65535),
+									},
+
+									"protocol": {
+										Type:             schema.TypeString,
+										Optional:         true,
+										ForceNew:         true,
+										Computed:         true,
+										DiffSuppressFunc: ignoreCaseDiffSuppressFunc,
+										ValidateFunc: validation.StringInSlice([]string{
+											"tcp",
+											"udp",
+										}, true),
+									},
+								},
+							},
+						},
+
+						"environment_variables": {
+							Type:         schema.TypeMap,
+							Optional:     true,
+							ForceNew:     true,
+							ValidateFunc: validateContainerGroupEnvironmentVariableNames,
 						},
 
 						"command": {
@@ -185,6 +479,28 @@ func resourceArmContainerGroup() *schema.Resource {
 							Elem:     &schema.Schema{Type: schema.TypeString},
 						},
 
+						"current_state": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"restart_count": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"start_time": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"exit_code": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"events": containerGroupEventsSchema(),
+
 						"volume": {
 							Type:     schema.TypeList,
 							Optional: true,
@@ -210,23 +526,113 @@ func resourceArmContainerGroup() *schema.Resource {
 										Default:  false,
 									},
 
-									"share_name": {
-										Type:     schema.TypeString,
-										Required: true,
+									"empty_dir": {
+										Type:     schema.TypeBool,
+										Optional: true,
+										ForceNew: true,
+										Default:  false,
+									},
+
+									"secret": {
+										Type:      schema.TypeMap,
+										Optional:  true,
+										ForceNew:  true,
+										Sensitive: true,
+									},
+
+									"git_repo": {
+										Type:     schema.TypeList,
+										Optional: true,
 										ForceNew: true,
+										MaxItems: 1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"url": {
+													Type:         schema.TypeString,
+													Required:     true,
+													ForceNew:     true,
+													ValidateFunc: validation.NoZeroValues,
+												},
+
+												"directory": {
+													Type:     schema.TypeString,
+													Optional: true,
+													ForceNew: true,
+												},
+
+												"revision": {
+													Type:     schema.TypeString,
+													Optional: true,
+													ForceNew: true,
+												},
+											},
+										},
+									},
+
+									"share_name": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										ForceNew:     true,
+										ValidateFunc: validation.NoZeroValues,
 									},
 
 									"storage_account_name": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										Computed:     true,
+										ForceNew:     true,
+										ValidateFunc: validateArmStorageAccountName,
+									},
+
+									"storage_account_id": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										ForceNew:     true,
+										ValidateFunc: azure.ValidateResourceID,
+									},
+
+									"storage_account_key": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										Sensitive:    true,
+										ForceNew:     true,
+										ValidateFunc: validation.NoZeroValues,
+									},
+
+									"storage_account_key_version": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										ForceNew:     true,
+										ValidateFunc: validation.NoZeroValues,
+									},
+								},
+							},
+						},
+
+						"volume_mount": {
+							Type:     schema.TypeList,
+							Optional: true,
+							ForceNew: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
 										Type:     schema.TypeString,
 										Required: true,
 										ForceNew: true,
 									},
 
-									"storage_account_key": {
+									"mount_path": {
 										Type:     schema.TypeString,
 										Required: true,
 										ForceNew: true,
 									},
+
+									"read_only": {
+										Type:     schema.TypeBool,
+										Optional: true,
+										ForceNew: true,
+										Default:  false,
+									},
 								},
 							},
 						},
@@ -237,19 +643,161 @@ func resourceArmContainerGroup() *schema.Resource {
 	}
 }
 
-func resourceArmContainerGroupCreate(d *schema.ResourceData, meta interface{}) error {
-	ctx := meta.(*ArmClient).StopContext
-	containerGroupsClient := meta.(*ArmClient).containerGroupsClient
+func containerGroupEventsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+
+				"message": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+
+				"count": {
+					Type:     schema.TypeInt,
+					Computed: true,
+				},
+
+				"first_timestamp": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+
+				"last_timestamp": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+
+				"type": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func resourceArmContainerGroupCreate(d *schema.ResourceData, meta interface{}) error {
+	ctx := meta.(*ArmClient).StopContext
+	containerGroupsClient := meta.(*ArmClient).containerGroupsClient
 
 	resGroup := d.Get("resource_group_name").(string)
 	name := d.Get("name").(string)
 	location := azureRMNormalizeLocation(d.Get("location").(string))
+
+	// fail fast, rather than with an opaque error from the API part-way through the apply
+	if err := validateContainerGroupTotalResources(d.Get("os_type").(string), d.Get("container").([]interface{})); err != nil {
+		return err
+	}
+
+	containerGroup, err := expandContainerGroup(d)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("validate_images").(bool) {
+		images := make([]string, 0)
+		for _, c := range d.Get("container").([]interface{}) {
+			images = append(images, c.(map[string]interface{})["image"].(string))
+		}
+
+		credentials := make(map[string]containerRegistryCredential)
+		for _, c := range d.Get("image_registry_credential").([]interface{}) {
+			credConfig := c.(map[string]interface{})
+			credentials[normalizeContainerRegistryServer(credConfig["server"].(string))] = containerRegistryCredential{
+				Username: credConfig["username"].(string),
+				Password: credConfig["password"].(string),
+			}
+		}
+
+		if err := validateContainerImages(ctx, newContainerImageValidationClient(), images, credentials); err != nil {
+			return err
+		}
+	}
+
+	// ACI intermittently returns throttling/server errors under load, which succeed when retried
+	var future containerinstance.ContainerGroupsCreateOrUpdateFuture
+	err = resource.Retry(d.Timeout(schema.TimeoutCreate), func() *resource.RetryError {
+		var err error
+		future, err = containerGroupsClient.CreateOrUpdate(ctx, resGroup, name, *containerGroup)
+		if err != nil {
+			if !isContainerGroupQuotaError(err) && isContainerGroupRetryableError(err) {
+				log.Printf("[DEBUG] Retrying creation of Container Group %q (Resource Group %q): %+v", name, resGroup, err)
+				return resource.RetryableError(err)
+			}
+
+			return resource.NonRetryableError(err)
+		}
+
+		return nil
+	})
+	if err == nil {
+		// errors during provisioning (e.g. a deployment failing asynchronously) are only returned by the long-running operation
+		err = future.WaitForCompletionRef(ctx, containerGroupsClient.Client)
+	}
+	if err != nil {
+		if isContainerGroupQuotaError(err) {
+			usageClient := meta.(*ArmClient).containerGroupUsageClient
+			usages, usageErr := usageClient.List(ctx, location)
+			if usageErr != nil {
+				log.Printf("[WARN] Error retrieving the Container Instance usage for %q: %+v", location, usageErr)
+			} else {
+				return fmt.Errorf("Error creating Container Group %q (Resource Group %q) since the Container Instance quota in %q has been reached (%s) - request a quota increase or use another location: %+v", name, resGroup, location, formatContainerGroupUsages(usages.Value), err)
+			}
+		}
+
+		return fmt.Errorf("Error creating Container Group %q (Resource Group %q): %+v", name, resGroup, err)
+	}
+
+	// the long-running operation can complete before the Container Group has finished provisioning,
+	// so wait for it to be available before reading it back
+	if err := waitForContainerGroupToBeAvailable(meta.(*ArmClient), resGroup, name, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	read, err := containerGroupsClient.Get(ctx, resGroup, name)
+	if err != nil {
+		return err
+	}
+
+	if read.ID == nil {
+		return fmt.Errorf("Cannot read container group %s (resource group %s) ID", name, resGroup)
+	}
+
+	d.SetId(*read.ID)
+
+	return resourceArmContainerGroupRead(d, meta)
+}
+
+func expandContainerGroup(d *schema.ResourceData) (*containerinstance.ContainerGroup, error) {
+	name := d.Get("name").(string)
+	location := azureRMNormalizeLocation(d.Get("location").(string))
 	OSType := d.Get("os_type").(string)
 	IPAddressType := d.Get("ip_address_type").(string)
 	tags := d.Get("tags").(map[string]interface{})
 	restartPolicy := d.Get("restart_policy").(string)
 
-	containers, containerGroupPorts, containerGroupVolumes := expandContainerGroupContainers(d)
+	containers, containerGroupPorts, containerGroupVolumes, err := expandContainerGroupContainers(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// when specified the exposed ports are used as-is, rather than exposing every port opened by the containers
+	if v, ok := d.GetOk("exposed_port"); ok {
+		exposedPorts := v.(*schema.Set).List()
+		if err := validateContainerGroupExposedPorts(exposedPorts, d.Get("container").([]interface{})); err != nil {
+			return nil, err
+		}
+
+		containerGroupPorts = expandContainerGroupExposedPorts(exposedPorts)
+	}
+
 	containerGroup := containerinstance.ContainerGroup{
 		Name:     &name,
 		Location: &location,
@@ -271,23 +819,23 @@ func resourceArmContainerGroupCreate(d *schema.ResourceData, meta interface{}) e
 		containerGroup.ContainerGroupProperties.IPAddress.DNSNameLabel = &dnsNameLabel
 	}
 
-	_, err := containerGroupsClient.CreateOrUpdate(ctx, resGroup, name, containerGroup)
-	if err != nil {
-		return err
-	}
+	return &containerGroup, nil
+}
 
-	read, err := containerGroupsClient.Get(ctx, resGroup, name)
-	if err != nil {
-		return err
+func waitForContainerGroupToBeAvailable(client *ArmClient, resourceGroup string, name string, timeout time.Duration) error {
+	log.Printf("[DEBUG] Waiting for Container Group %q (Resource Group %q) to become available", name, resourceGroup)
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"Pending", "Creating", "Updating", "Repairing"},
+		Target:     []string{"Succeeded"},
+		Refresh:    containerGroupStateRefreshFunc(client, resourceGroup, name),
+		Timeout:    timeout,
+		MinTimeout: 5 * time.Second,
 	}
-
-	if read.ID == nil {
-		return fmt.Errorf("Cannot read container group %s (resource group %s) ID", name, resGroup)
+	if _, err := stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error waiting for Container Group %q (Resource Group %q) to become available: %+v", name, resourceGroup, err)
 	}
 
-	d.SetId(*read.ID)
-
-	return resourceArmContainerGroupRead(d, meta)
+	return nil
 }
 
 func resourceArmContainerGroupRead(d *schema.ResourceData, meta interface{}) error {
@@ -320,11 +868,23 @@ func resourceArmContainerGroupRead(d *schema.ResourceData, meta interface{}) err
 	}
 
 	if props := resp.ContainerGroupProperties; props != nil {
-		containerConfigs := flattenContainerGroupContainers(d, resp.Containers, props.IPAddress.Ports, props.Volumes)
+		var containerGroupPorts *[]containerinstance.Port
+		if address := props.IPAddress; address != nil {
+			containerGroupPorts = address.Ports
+		}
+
+		containerConfigs := flattenContainerGroupContainers(d, resp.Containers, containerGroupPorts, props.Volumes)
 		if err := d.Set("container", containerConfigs); err != nil {
 			return fmt.Errorf("Error setting `container`: %+v", err)
 		}
 
+		volumeConfigs := flattenContainerGroupVolumes(d, props.Volumes)
+		if err := d.Set("volume", volumeConfigs); err != nil {
+			return fmt.Errorf("Error setting `volume`: %+v", err)
+		}
+
+		d.Set("compose_yaml", renderContainerGroupComposeYaml(containerConfigs, volumeConfigs))
+
 		if err := d.Set("image_registry_credential", flattenContainerImageRegistryCredentials(d, props.ImageRegistryCredentials)); err != nil {
 			return fmt.Errorf("Error setting `capabilities`: %+v", err)
 		}
@@ -334,16 +894,101 @@ func resourceArmContainerGroupRead(d *schema.ResourceData, meta interface{}) err
 			d.Set("ip_address", address.IP)
 			d.Set("dns_name_label", address.DNSNameLabel)
 			d.Set("fqdn", address.Fqdn)
+
+			fqdnRegion := ""
+			if address.Fqdn != nil && *address.Fqdn != "" {
+				fqdnRegion = containerGroupFqdnRegion(*address.Fqdn)
+				warnOnContainerGroupFqdnMismatch(name, resourceGroup, address, resp.Location)
+			}
+			d.Set("fqdn_region", fqdnRegion)
+		}
+
+		exposedPorts := flattenContainerGroupExposedPorts(containerGroupPorts)
+		if err := d.Set("exposed_port", exposedPorts); err != nil {
+			return fmt.Errorf("Error setting `exposed_port`: %+v", err)
+		}
+		if err := d.Set("exposed_ports", exposedPorts); err != nil {
+			return fmt.Errorf("Error setting `exposed_ports`: %+v", err)
+		}
+
+		if err := d.Set("endpoints", flattenContainerGroupEndpoints(props.IPAddress)); err != nil {
+			return fmt.Errorf("Error setting `endpoints`: %+v", err)
 		}
 
 		d.Set("restart_policy", string(props.RestartPolicy))
 		d.Set("os_type", string(props.OsType))
+
+		state := containerGroupInstanceState(props)
+		d.Set("state", state)
+		d.Set("is_terminal", containerGroupIsTerminal(props.RestartPolicy, state))
+
+		var events *[]containerinstance.Event
+		if instanceView := props.InstanceView; instanceView != nil {
+			events = instanceView.Events
+		}
+		if err := d.Set("events", flattenContainerGroupEvents(events)); err != nil {
+			return fmt.Errorf("Error setting `events`: %+v", err)
+		}
 	}
 	flattenAndSetTags(d, resp.Tags)
 
+	// not returned from the API, so default this when importing
+	d.Set("allow_fqdn_change", d.Get("allow_fqdn_change").(bool))
+	d.Set("validate_images", d.Get("validate_images").(bool))
+
 	return nil
 }
 
+func resourceArmContainerGroupUpdate(d *schema.ResourceData, meta interface{}) error {
+	ctx := meta.(*ArmClient).StopContext
+	client := meta.(*ArmClient).containerGroupsClient
+
+	id, err := parseAzureResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	resourceGroup := id.ResourceGroup
+	name := id.Path["containerGroups"]
+
+	// the image registry credentials can only be changed by re-submitting the whole Container Group,
+	// which relies on the secrets (e.g. storage account keys) the API doesn't return being in the config
+	if d.HasChange("image_registry_credential") {
+		containerGroup, err := expandContainerGroup(d)
+		if err != nil {
+			return err
+		}
+
+		if _, err := client.CreateOrUpdate(ctx, resourceGroup, name, *containerGroup); err != nil {
+			return fmt.Errorf("Error updating Container Group %q (Resource Group %q): %+v", name, resourceGroup, err)
+		}
+
+		if err := waitForContainerGroupToBeAvailable(meta.(*ArmClient), resourceGroup, name, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+
+		return resourceArmContainerGroupRead(d, meta)
+	}
+
+	// `allow_fqdn_change` and `validate_images` can also be updated in-place, but they're only used by the provider
+	if !d.HasChange("tags") {
+		return resourceArmContainerGroupRead(d, meta)
+	}
+
+	tags := d.Get("tags").(map[string]interface{})
+
+	// the tags are patched, since a PUT would re-submit the whole Container Group
+	props := containerinstance.Resource{
+		Tags: expandTags(tags),
+	}
+
+	if _, err := client.Update(ctx, resourceGroup, name, props); err != nil {
+		return fmt.Errorf("Error updating the tags of Container Group %q (Resource Group %q): %+v", name, resourceGroup, err)
+	}
+
+	return resourceArmContainerGroupRead(d, meta)
+}
+
 func resourceArmContainerGroupDelete(d *schema.ResourceData, meta interface{}) error {
 	ctx := meta.(*ArmClient).StopContext
 	client := meta.(*ArmClient).containerGroupsClient
@@ -361,13 +1006,243 @@ func resourceArmContainerGroupDelete(d *schema.ResourceData, meta interface{}) e
 		if !utils.ResponseWasNotFound(resp.Response) {
 			return fmt.Errorf("Error deleting Container Group %q (Resource Group %q): %+v", name, resourceGroup, err)
 		}
+
+		return nil
+	}
+
+	// the Container Group can linger whilst it's being torn down, which blocks deleting the resources it uses
+	log.Printf("[DEBUG] Waiting for Container Group %q (Resource Group %q) to be deleted", name, resourceGroup)
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"Deleting"},
+		Target:     []string{"NotFound"},
+		Refresh:    containerGroupDeleteRefreshFunc(meta.(*ArmClient), resourceGroup, name),
+		Timeout:    d.Timeout(schema.TimeoutDelete),
+		MinTimeout: 5 * time.Second,
+	}
+	if _, err := stateConf.WaitForState(); err != nil {
+		return fmt.Errorf("Error waiting for Container Group %q (Resource Group %q) to be deleted: %+v", name, resourceGroup, err)
 	}
 
 	return nil
 }
 
+func containerGroupStateRefreshFunc(client *ArmClient, resourceGroupName string, containerGroupName string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		ctx := client.StopContext
+		res, err := client.containerGroupsClient.Get(ctx, resourceGroupName, containerGroupName)
+		if err != nil {
+			return nil, "", fmt.Errorf("Error retrieving Container Group %q (Resource Group %q): %+v", containerGroupName, resourceGroupName, err)
+		}
+
+		return res, containerGroupProvisioningState(res.ContainerGroupProperties), nil
+	}
+}
+
+func containerGroupDeleteRefreshFunc(client *ArmClient, resourceGroupName string, containerGroupName string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		ctx := client.StopContext
+		res, err := client.containerGroupsClient.Get(ctx, resourceGroupName, containerGroupName)
+		if err != nil {
+			if utils.ResponseWasNotFound(res.Response) {
+				return res, "NotFound", nil
+			}
+
+			return nil, "", fmt.Errorf("Error retrieving Container Group %q (Resource Group %q): %+v", containerGroupName, resourceGroupName, err)
+		}
+
+		return res, "Deleting", nil
+	}
+}
+
+func containerGroupProvisioningState(props *containerinstance.ContainerGroupProperties) string {
+	// the provisioning state (and instance view) aren't populated early on in provisioning
+	if props == nil || props.ProvisioningState == nil || *props.ProvisioningState == "" {
+		return "Pending"
+	}
+
+	return *props.ProvisioningState
+}
+
+func isContainerGroupRetryableError(err error) bool {
+	detailed, ok := err.(autorest.DetailedError)
+	if !ok {
+		return false
+	}
+
+	// the status code of an error response from the API is held on the wrapped RequestError
+	if requestErr, ok := detailed.Original.(*azureautorest.RequestError); ok {
+		detailed = requestErr.DetailedError
+	}
+
+	switch detailed.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable:
+		return true
+	}
+
+	return false
+}
+
+func isContainerGroupQuotaError(err error) bool {
+	// the error codes differ depending on the quota which has been reached (e.g. `ContainerGroupQuotaReached`,
+	// `StandardCoresQuotaReached`) - the message isn't checked since it can contain the user's resource names
+	code := containerGroupServiceErrorCode(err)
+	return strings.HasSuffix(code, "QuotaReached") || strings.HasSuffix(code, "QuotaExceeded")
+}
+
+// containerGroupServiceErrorCode returns the code of the service error returned from the API, if there is one
+func containerGroupServiceErrorCode(err error) string {
+	if detailed, ok := err.(autorest.DetailedError); ok {
+		err = detailed.Original
+	}
+
+	switch e := err.(type) {
+	case *azureautorest.RequestError:
+		if e.ServiceError != nil {
+			return e.ServiceError.Code
+		}
+	case *azureautorest.ServiceError:
+		return e.Code
+	}
+
+	return ""
+}
+
+func formatContainerGroupUsages(input *[]containerinstance.Usage) string {
+	if input == nil {
+		return "no usage information available"
+	}
+
+	usages := make([]string, 0)
+	for _, usage := range *input {
+		if usage.Name == nil || usage.CurrentValue == nil || usage.Limit == nil {
+			continue
+		}
+
+		name := ""
+		if usage.Name.LocalizedValue != nil && *usage.Name.LocalizedValue != "" {
+			name = *usage.Name.LocalizedValue
+		} else if usage.Name.Value != nil {
+			name = *usage.Name.Value
+		}
+
+		usages = append(usages, fmt.Sprintf("%s: %d of %d", name, *usage.CurrentValue, *usage.Limit))
+	}
+
+	if len(usages) == 0 {
+		return "no usage information available"
+	}
+
+	sort.Strings(usages)
+	return strings.Join(usages, ", ")
+}
+
+// containerGroupInstanceState returns the state of the Container Group, distinguishing a
+// batch group which has run to completion from one where a container has crashed.
+func containerGroupInstanceState(props *containerinstance.ContainerGroupProperties) string {
+	state := ""
+	if view := props.InstanceView; view != nil && view.State != nil {
+		state = *view.State
+	}
+
+	if props.Containers == nil || len(*props.Containers) == 0 {
+		return state
+	}
+
+	succeeded := true
+	for _, container := range *props.Containers {
+		if container.ContainerProperties == nil || container.InstanceView == nil {
+			return state
+		}
+
+		current := container.InstanceView.CurrentState
+		if current == nil || current.State == nil || !strings.EqualFold(*current.State, "Terminated") {
+			return state
+		}
+
+		if current.ExitCode == nil || *current.ExitCode != 0 {
+			succeeded = false
+		}
+	}
+
+	// when the restart policy is `Always` a terminated container is about to be restarted
+	if props.RestartPolicy == containerinstance.Always {
+		return state
+	}
+
+	if succeeded {
+		return "Completed"
+	}
+
+	return "Failed"
+}
+
+// containerGroupIsTerminal returns whether every container has exited and the
+// restart policy means they won't be started again.
+func containerGroupIsTerminal(restartPolicy containerinstance.ContainerGroupRestartPolicy, state string) bool {
+	switch restartPolicy {
+	case containerinstance.Never:
+		return state == "Completed" || state == "Failed"
+	case containerinstance.OnFailure:
+		return state == "Completed"
+	}
+
+	return false
+}
+
+// validateContainerGroupFqdnChange returns an error if a change would replace the FQDN without `allow_fqdn_change`
+func validateContainerGroupFqdnChange(oldLabel string, newLabel string, oldLocation string, newLocation string, allowFqdnChange bool) error {
+	// without an existing DNS Name Label there's no FQDN to protect
+	if allowFqdnChange || oldLabel == "" {
+		return nil
+	}
+
+	if oldLabel == newLabel && azureRMNormalizeLocation(oldLocation) == azureRMNormalizeLocation(newLocation) {
+		return nil
+	}
+
+	return fmt.Errorf("This change would replace the FQDN of the Container Group (DNS Name Label %q in %q) - set `allow_fqdn_change` to `true` to allow this", oldLabel, azureRMNormalizeLocation(oldLocation))
+}
+
+// containerGroupFqdnRegion returns the region component of a Container Group's FQDN,
+// which is in the format `{dnsNameLabel}.{region}.azurecontainer.io`
+func containerGroupFqdnRegion(fqdn string) string {
+	segments := strings.Split(strings.ToLower(fqdn), ".")
+	if len(segments) < 3 {
+		return ""
+	}
+
+	return segments[1]
+}
+
+// warnOnContainerGroupFqdnMismatch logs a warning when the FQDN isn't in the format `{dnsNameLabel}.{region}.azurecontainer.io`
+func warnOnContainerGroupFqdnMismatch(name string, resourceGroup string, address *containerinstance.IPAddress, location *string) {
+	if address == nil || address.Fqdn == nil || *address.Fqdn == "" || location == nil {
+		return
+	}
+
+	dnsNameLabel := ""
+	if address.DNSNameLabel != nil {
+		dnsNameLabel = *address.DNSNameLabel
+	}
+
+	if !containerGroupFqdnMatches(*address.Fqdn, dnsNameLabel, *location) {
+		log.Printf("[WARN] The FQDN %q of Container Group %q (Resource Group %q) doesn't match the expected format `%s.%s.azurecontainer.io`", *address.Fqdn, name, resourceGroup, dnsNameLabel, azureRMNormalizeLocation(*location))
+	}
+}
+
+func containerGroupFqdnMatches(fqdn string, dnsNameLabel string, location string) bool {
+	expected := fmt.Sprintf("%s.%s.azurecontainer.io", dnsNameLabel, azureRMNormalizeLocation(location))
+	return strings.EqualFold(fqdn, expected)
+}
+
 func flattenContainerGroupContainers(d *schema.ResourceData, containers *[]containerinstance.Container, containerGroupPorts *[]containerinstance.Port, containerGroupVolumes *[]containerinstance.Volume) []interface{} {
 
+	sharedVolumeNames := make(map[string]bool)
+	for _, v := range d.Get("volume").([]interface{}) {
+		volumeConfig := v.(map[string]interface{})
+		sharedVolumeNames[volumeConfig["name"].(string)] = true
+	}
+
 	containerConfigs := make([]interface{}, 0, len(*containers))
 	for _, container := range *containers {
 		containerConfig := make(map[string]interface{})
@@ -379,21 +1254,23 @@ func flattenContainerGroupContainers(d *schema.ResourceData, containers *[]conta
 				containerConfig["cpu"] = *resourceRequests.CPU
 				containerConfig["memory"] = *resourceRequests.MemoryInGB
 			}
-		}
-
-		if len(*container.Ports) > 0 {
-			containerPort := *(*container.Ports)[0].Port
-			containerConfig["port"] = containerPort
-			// protocol isn't returned in container config, have to search in container group ports
-			protocol := ""
-			if containerGroupPorts != nil {
-				for _, cgPort := range *containerGroupPorts {
-					if *cgPort.Port == containerPort {
-						protocol = string(cgPort.Protocol)
-					}
+			if resourceLimits := resources.Limits; resourceLimits != nil {
+				if resourceLimits.CPU != nil {
+					containerConfig["cpu_limit"] = *resourceLimits.CPU
+				}
+				if resourceLimits.MemoryInGB != nil {
+					containerConfig["memory_limit"] = *resourceLimits.MemoryInGB
 				}
 			}
-			if protocol != "" {
+		}
+
+		ports := flattenContainerPorts(container.Ports, containerGroupPorts)
+		containerConfig["ports"] = ports
+		if len(ports) > 0 {
+			// the deprecated `port` and `protocol` fields only ever held the first port
+			firstPort := ports[0].(map[string]interface{})
+			containerConfig["port"] = int32(firstPort["port"].(int))
+			if protocol := firstPort["protocol"].(string); protocol != "" {
 				containerConfig["protocol"] = protocol
 			}
 		}
@@ -414,7 +1291,11 @@ func flattenContainerGroupContainers(d *schema.ResourceData, containers *[]conta
 		}
 		containerConfig["commands"] = commands
 
-		if containerGroupVolumes != nil && container.VolumeMounts != nil {
+		// mounts of group-level volumes are returned alongside the inline volumes, so split them out
+		volumeMounts, sharedVolumeMounts := splitContainerVolumeMounts(container.VolumeMounts, sharedVolumeNames)
+		containerConfig["volume_mount"] = flattenContainerVolumeMounts(sharedVolumeMounts)
+
+		if containerGroupVolumes != nil && volumeMounts != nil {
 			// Also pass in the container volume config from schema
 			var containerVolumesConfig *[]interface{}
 			containersConfigRaw := d.Get("container").([]interface{})
@@ -430,32 +1311,318 @@ func flattenContainerGroupContainers(d *schema.ResourceData, containers *[]conta
 					}
 				}
 			}
-			containerConfig["volume"] = flattenContainerVolumes(container.VolumeMounts, containerGroupVolumes, containerVolumesConfig)
+			containerConfig["volume"] = flattenContainerVolumes(volumeMounts, containerGroupVolumes, containerVolumesConfig)
 		}
 
+		flattenContainerInstanceView(containerConfig, container.InstanceView)
+
 		containerConfigs = append(containerConfigs, containerConfig)
 	}
 
 	return containerConfigs
 }
 
-func flattenContainerEnvironmentVariables(input *[]containerinstance.EnvironmentVariable) map[string]interface{} {
-	output := make(map[string]interface{})
-	if input == nil {
-		return output
-	}
+func flattenContainerInstanceView(containerConfig map[string]interface{}, instanceView *containerinstance.ContainerPropertiesInstanceView) {
+	currentState := ""
+	restartCount := 0
+	startTime := ""
+	exitCode := 0
 
-	for _, envVar := range *input {
-		if envVar.Name != nil && envVar.Value != nil {
-			output[*envVar.Name] = *envVar.Value
+	if instanceView != nil {
+		if instanceView.RestartCount != nil {
+			restartCount = int(*instanceView.RestartCount)
+		}
+
+		if state := instanceView.CurrentState; state != nil {
+			if state.State != nil {
+				currentState = *state.State
+			}
+			if state.StartTime != nil {
+				startTime = state.StartTime.Format(time.RFC3339)
+			}
+			if state.ExitCode != nil {
+				exitCode = int(*state.ExitCode)
+			}
 		}
 	}
 
-	return output
-}
+	containerConfig["current_state"] = currentState
+	containerConfig["restart_count"] = restartCount
+	containerConfig["start_time"] = startTime
+	containerConfig["exit_code"] = exitCode
 
-func flattenContainerVolumes(volumeMounts *[]containerinstance.VolumeMount, containerGroupVolumes *[]containerinstance.Volume, containerVolumesConfig *[]interface{}) []interface{} {
-	volumeConfigs := make([]interface{}, 0)
+	var events *[]containerinstance.Event
+	if instanceView != nil {
+		events = instanceView.Events
+	}
+	containerConfig["events"] = flattenContainerGroupEvents(events)
+}
+
+func flattenContainerGroupEvents(input *[]containerinstance.Event) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	for _, event := range *input {
+		values := make(map[string]interface{})
+
+		if event.Name != nil {
+			values["name"] = *event.Name
+		}
+		if event.Message != nil {
+			values["message"] = *event.Message
+		}
+		if event.Count != nil {
+			values["count"] = int(*event.Count)
+		}
+		if event.FirstTimestamp != nil {
+			values["first_timestamp"] = event.FirstTimestamp.Format(time.RFC3339)
+		}
+		if event.LastTimestamp != nil {
+			values["last_timestamp"] = event.LastTimestamp.Format(time.RFC3339)
+		}
+		if event.Type != nil {
+			values["type"] = *event.Type
+		}
+
+		output = append(output, values)
+	}
+
+	return output
+}
+
+func expandContainerPortsConfig(data map[string]interface{}) []interface{} {
+	portsConfig := make([]interface{}, 0)
+	if v, ok := data["ports"]; ok {
+		portsConfig = v.([]interface{})
+	}
+
+	// fall back to the deprecated `port` and `protocol` fields
+	if len(portsConfig) == 0 {
+		if v, ok := data["port"]; ok && v.(int) != 0 {
+			portConfig := map[string]interface{}{
+				"port":     v.(int),
+				"protocol": "",
+			}
+			if v, ok := data["protocol"]; ok {
+				portConfig["protocol"] = v.(string)
+			}
+
+			portsConfig = append(portsConfig, portConfig)
+		}
+	}
+
+	return portsConfig
+}
+
+func expandContainerGroupExposedPorts(input []interface{}) *[]containerinstance.Port {
+	ports := make([]containerinstance.Port, 0, len(input))
+	for _, v := range input {
+		config := v.(map[string]interface{})
+		ports = append(ports, containerinstance.Port{
+			Port:     utils.Int32(int32(config["port"].(int))),
+			Protocol: containerinstance.ContainerGroupNetworkProtocol(strings.ToUpper(config["protocol"].(string))),
+		})
+	}
+
+	return &ports
+}
+
+func resourceArmContainerGroupExposedPortHash(v interface{}) int {
+	var buf bytes.Buffer
+
+	if m, ok := v.(map[string]interface{}); ok {
+		buf.WriteString(fmt.Sprintf("%d-", m["port"].(int)))
+		if protocol, ok := m["protocol"]; ok {
+			buf.WriteString(fmt.Sprintf("%s-", strings.ToUpper(protocol.(string))))
+		}
+	}
+
+	return hashcode.String(buf.String())
+}
+
+func flattenContainerGroupExposedPorts(input *[]containerinstance.Port) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	ports := make([]containerinstance.Port, 0, len(*input))
+	for _, port := range *input {
+		if port.Port != nil {
+			ports = append(ports, port)
+		}
+	}
+
+	// the API doesn't guarantee the ordering of the ports, so sort them to keep the list stable
+	sort.Slice(ports, func(i, j int) bool {
+		if *ports[i].Port != *ports[j].Port {
+			return *ports[i].Port < *ports[j].Port
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+
+	for _, port := range ports {
+		output = append(output, map[string]interface{}{
+			"port":     int(*port.Port),
+			"protocol": string(port.Protocol),
+		})
+	}
+
+	return output
+}
+
+func flattenContainerGroupEndpoints(input *containerinstance.IPAddress) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	// prefer the FQDN, since the IP Address can change when the Container Group is restarted
+	host := ""
+	if input.Fqdn != nil && *input.Fqdn != "" {
+		host = *input.Fqdn
+	} else if input.IP != nil {
+		host = *input.IP
+	}
+
+	if host == "" {
+		return output
+	}
+
+	for _, v := range flattenContainerGroupExposedPorts(input.Ports) {
+		port := v.(map[string]interface{})
+		output = append(output, map[string]interface{}{
+			"port":     port["port"],
+			"protocol": port["protocol"],
+			"endpoint": net.JoinHostPort(host, strconv.Itoa(port["port"].(int))),
+		})
+	}
+
+	return output
+}
+
+func flattenContainerPorts(input *[]containerinstance.ContainerPort, containerGroupPorts *[]containerinstance.Port) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	for _, containerPort := range *input {
+		if containerPort.Port == nil {
+			continue
+		}
+
+		// protocol isn't always returned in container config, have to search in container group ports
+		protocol := string(containerPort.Protocol)
+		if protocol == "" && containerGroupPorts != nil {
+			for _, cgPort := range *containerGroupPorts {
+				if cgPort.Port != nil && *cgPort.Port == *containerPort.Port {
+					protocol = string(cgPort.Protocol)
+				}
+			}
+		}
+
+		output = append(output, map[string]interface{}{
+			"port":     int(*containerPort.Port),
+			"protocol": protocol,
+		})
+	}
+
+	return output
+}
+
+func flattenContainerEnvironmentVariables(input *[]containerinstance.EnvironmentVariable) map[string]interface{} {
+	output := make(map[string]interface{})
+	if input == nil {
+		return output
+	}
+
+	for _, envVar := range *input {
+		if envVar.Name != nil && envVar.Value != nil {
+			output[*envVar.Name] = *envVar.Value
+		}
+	}
+
+	return output
+}
+
+func splitContainerVolumeMounts(input *[]containerinstance.VolumeMount, sharedVolumeNames map[string]bool) (*[]containerinstance.VolumeMount, *[]containerinstance.VolumeMount) {
+	if input == nil {
+		return nil, nil
+	}
+
+	volumeMounts := make([]containerinstance.VolumeMount, 0)
+	sharedVolumeMounts := make([]containerinstance.VolumeMount, 0)
+	for _, vm := range *input {
+		if vm.Name != nil && sharedVolumeNames[*vm.Name] {
+			sharedVolumeMounts = append(sharedVolumeMounts, vm)
+			continue
+		}
+
+		volumeMounts = append(volumeMounts, vm)
+	}
+
+	return &volumeMounts, &sharedVolumeMounts
+}
+
+func flattenContainerVolumeMounts(input *[]containerinstance.VolumeMount) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	for _, vm := range *input {
+		volumeMount := make(map[string]interface{})
+		if vm.Name != nil {
+			volumeMount["name"] = *vm.Name
+		}
+		if vm.MountPath != nil {
+			volumeMount["mount_path"] = *vm.MountPath
+		}
+		if vm.ReadOnly != nil {
+			volumeMount["read_only"] = *vm.ReadOnly
+		}
+
+		output = append(output, volumeMount)
+	}
+
+	return output
+}
+
+func flattenContainerGroupVolumes(d *schema.ResourceData, input *[]containerinstance.Volume) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	// only the group-level volumes defined in the config are flattened here, since the
+	// volumes defined inline within a `container` block are also returned by the API
+	volumesConfig := d.Get("volume").([]interface{})
+	for _, v := range volumesConfig {
+		volumeConfig := v.(map[string]interface{})
+		name := volumeConfig["name"].(string)
+
+		for _, cgv := range *input {
+			if cgv.Name == nil || *cgv.Name != name {
+				continue
+			}
+
+			volume := map[string]interface{}{
+				"name": name,
+			}
+			flattenContainerVolumeSource(volume, cgv, volumeConfig)
+
+			output = append(output, volume)
+		}
+	}
+
+	return output
+}
+
+func flattenContainerVolumes(volumeMounts *[]containerinstance.VolumeMount, containerGroupVolumes *[]containerinstance.Volume, containerVolumesConfig *[]interface{}) []interface{} {
+	volumeConfigs := make([]interface{}, 0)
 
 	if volumeMounts == nil {
 		return volumeConfigs
@@ -473,6 +1640,18 @@ func flattenContainerVolumes(volumeMounts *[]containerinstance.VolumeMount, cont
 			volumeConfig["read_only"] = *vm.ReadOnly
 		}
 
+		// find corresponding volume in config, since some of the values aren't returned by the API
+		config := make(map[string]interface{})
+		if containerVolumesConfig != nil {
+			for _, cvr := range *containerVolumesConfig {
+				cv := cvr.(map[string]interface{})
+				rawName := cv["name"].(string)
+				if vm.Name != nil && *vm.Name == rawName {
+					config = cv
+				}
+			}
+		}
+
 		// find corresponding volume in container group volumes
 		// and use the data
 		if containerGroupVolumes != nil {
@@ -482,28 +1661,7 @@ func flattenContainerVolumes(volumeMounts *[]containerinstance.VolumeMount, cont
 				}
 
 				if *cgv.Name == *vm.Name {
-					if file := cgv.AzureFile; file != nil {
-						if file.ShareName != nil {
-							volumeConfig["share_name"] = *file.ShareName
-						}
-						if file.StorageAccountName != nil {
-							volumeConfig["storage_account_name"] = *file.StorageAccountName
-						}
-						// skip storage_account_key, is always nil
-					}
-				}
-			}
-		}
-
-		// find corresponding volume in config
-		// and use the data
-		if containerVolumesConfig != nil {
-			for _, cvr := range *containerVolumesConfig {
-				cv := cvr.(map[string]interface{})
-				rawName := cv["name"].(string)
-				if vm.Name != nil && *vm.Name == rawName {
-					storageAccountKey := cv["storage_account_key"].(string)
-					volumeConfig["storage_account_key"] = storageAccountKey
+					flattenContainerVolumeSource(volumeConfig, cgv, config)
 				}
 			}
 		}
@@ -514,12 +1672,70 @@ func flattenContainerVolumes(volumeMounts *[]containerinstance.VolumeMount, cont
 	return volumeConfigs
 }
 
-func expandContainerGroupContainers(d *schema.ResourceData) (*[]containerinstance.Container, *[]containerinstance.Port, *[]containerinstance.Volume) {
+func flattenContainerVolumeSource(output map[string]interface{}, input containerinstance.Volume, config map[string]interface{}) {
+	output["empty_dir"] = input.EmptyDir != nil
+
+	gitRepos := make([]interface{}, 0)
+	if gitRepo := input.GitRepo; gitRepo != nil {
+		repo := make(map[string]interface{})
+		if gitRepo.Repository != nil {
+			repo["url"] = *gitRepo.Repository
+		}
+		if gitRepo.Directory != nil {
+			repo["directory"] = *gitRepo.Directory
+		}
+		if gitRepo.Revision != nil {
+			repo["revision"] = *gitRepo.Revision
+		}
+		gitRepos = append(gitRepos, repo)
+	}
+	output["git_repo"] = gitRepos
+
+	// the contents of the secret files aren't returned by the API, so we pull them from the config
+	if input.Secret != nil {
+		if v, ok := config["secret"]; ok {
+			output["secret"] = v.(map[string]interface{})
+		}
+	}
+
+	if file := input.AzureFile; file != nil {
+		if file.ShareName != nil {
+			output["share_name"] = *file.ShareName
+		}
+		if file.StorageAccountName != nil {
+			output["storage_account_name"] = *file.StorageAccountName
+		}
+
+		// the storage account key & ID aren't returned by the API, so we pull them from the config
+		if v, ok := config["storage_account_key"]; ok {
+			output["storage_account_key"] = v.(string)
+		}
+		// the key version is only a trigger for recreating the Container Group when the key's rotated
+		if v, ok := config["storage_account_key_version"]; ok {
+			output["storage_account_key_version"] = v.(string)
+		}
+		if v, ok := config["storage_account_id"]; ok {
+			output["storage_account_id"] = v.(string)
+		}
+	}
+}
+
+func expandContainerGroupContainers(d *schema.ResourceData) (*[]containerinstance.Container, *[]containerinstance.Port, *[]containerinstance.Volume, error) {
 	containersConfig := d.Get("container").([]interface{})
 	containers := make([]containerinstance.Container, 0)
 	containerGroupPorts := make([]containerinstance.Port, 0)
 	containerGroupVolumes := make([]containerinstance.Volume, 0)
 
+	sharedVolumes, err := expandContainerGroupVolumes(d.Get("volume").([]interface{}))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sharedVolumeNames := make(map[string]bool)
+	for _, v := range sharedVolumes {
+		sharedVolumeNames[*v.Name] = true
+	}
+
 	for _, containerConfig := range containersConfig {
 		data := containerConfig.(map[string]interface{})
 
@@ -541,27 +1757,47 @@ func expandContainerGroupContainers(d *schema.ResourceData) (*[]containerinstanc
 			},
 		}
 
-		if v, _ := data["port"]; v != 0 {
-			port := int32(v.(int))
+		if v, ok := data["cpu_limit"]; ok && v.(float64) > 0 {
+			container.Resources.Limits = &containerinstance.ResourceLimits{}
+			container.Resources.Limits.CPU = utils.Float(v.(float64))
+		}
 
-			// container port (port number)
-			container.Ports = &[]containerinstance.ContainerPort{
-				{
-					Port: &port,
-				},
+		if v, ok := data["memory_limit"]; ok && v.(float64) > 0 {
+			if container.Resources.Limits == nil {
+				container.Resources.Limits = &containerinstance.ResourceLimits{}
 			}
+			container.Resources.Limits.MemoryInGB = utils.Float(v.(float64))
+		}
 
-			// container group port (port number + protocol)
-			containerGroupPort := containerinstance.Port{
-				Port: &port,
-			}
+		portsConfig := expandContainerPortsConfig(data)
+		if len(portsConfig) > 0 {
+			containerPorts := make([]containerinstance.ContainerPort, 0)
 
-			if v, ok := data["protocol"]; ok {
-				protocol := v.(string)
-				containerGroupPort.Protocol = containerinstance.ContainerGroupNetworkProtocol(strings.ToUpper(protocol))
+			for _, v := range portsConfig {
+				portConfig := v.(map[string]interface{})
+				port := int32(portConfig["port"].(int))
+				protocol := strings.ToUpper(portConfig["protocol"].(string))
+
+				// container port (port number)
+				containerPort := containerinstance.ContainerPort{
+					Port: utils.Int32(port),
+				}
+
+				// container group port (port number + protocol)
+				containerGroupPort := containerinstance.Port{
+					Port: utils.Int32(port),
+				}
+
+				if protocol != "" {
+					containerPort.Protocol = containerinstance.ContainerNetworkProtocol(protocol)
+					containerGroupPort.Protocol = containerinstance.ContainerGroupNetworkProtocol(protocol)
+				}
+
+				containerPorts = append(containerPorts, containerPort)
+				containerGroupPorts = append(containerGroupPorts, containerGroupPort)
 			}
 
-			containerGroupPorts = append(containerGroupPorts, containerGroupPort)
+			container.Ports = &containerPorts
 		}
 
 		if v, ok := data["environment_variables"]; ok {
@@ -570,12 +1806,14 @@ func expandContainerGroupContainers(d *schema.ResourceData) (*[]containerinstanc
 
 		if v, ok := data["commands"]; ok {
 			c := v.([]interface{})
-			command := make([]string, 0)
-			for _, v := range c {
-				command = append(command, v.(string))
-			}
+			if len(c) > 0 {
+				command := make([]string, 0)
+				for _, v := range c {
+					command = append(command, v.(string))
+				}
 
-			container.Command = &command
+				container.Command = &command
+			}
 		}
 
 		if container.Command == nil {
@@ -586,21 +1824,203 @@ func expandContainerGroupContainers(d *schema.ResourceData) (*[]containerinstanc
 		}
 
 		if v, ok := data["volume"]; ok {
-			volumeMounts, containerGroupVolumesPartial := expandContainerVolumes(v)
+			volumeMounts, containerGroupVolumesPartial, err := expandContainerVolumes(v)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("Error expanding the volumes of container %q: %+v", name, err)
+			}
 			container.VolumeMounts = volumeMounts
 			if containerGroupVolumesPartial != nil {
+				for _, cgv := range *containerGroupVolumesPartial {
+					if sharedVolumeNames[*cgv.Name] {
+						return nil, nil, nil, fmt.Errorf("The volume %q in container %q is also defined as a container group `volume` - use a `volume_mount` block to mount it instead", *cgv.Name, name)
+					}
+				}
 				containerGroupVolumes = append(containerGroupVolumes, *containerGroupVolumesPartial...)
 			}
 		}
 
+		if v, ok := data["volume_mount"]; ok {
+			volumeMounts := make([]containerinstance.VolumeMount, 0)
+			if container.VolumeMounts != nil {
+				volumeMounts = append(volumeMounts, *container.VolumeMounts...)
+			}
+
+			for _, vm := range expandContainerVolumeMounts(v) {
+				if !sharedVolumeNames[*vm.Name] {
+					return nil, nil, nil, fmt.Errorf("The `volume_mount` %q in container %q doesn't reference a container group `volume`", *vm.Name, name)
+				}
+				volumeMounts = append(volumeMounts, vm)
+			}
+
+			if len(volumeMounts) > 0 {
+				container.VolumeMounts = &volumeMounts
+			}
+		}
+
 		containers = append(containers, container)
 	}
 
-	return &containers, &containerGroupPorts, &containerGroupVolumes
+	containerGroupVolumes = append(containerGroupVolumes, sharedVolumes...)
+
+	// omitted blocks are sent as nil rather than empty lists, since the API doesn't echo empty lists back
+	var ports *[]containerinstance.Port
+	if len(containerGroupPorts) > 0 {
+		ports = &containerGroupPorts
+	}
+
+	var volumes *[]containerinstance.Volume
+	if len(containerGroupVolumes) > 0 {
+		volumes = &containerGroupVolumes
+	}
+
+	return &containers, ports, volumes, nil
+}
+
+func expandContainerGroupVolumes(input []interface{}) ([]containerinstance.Volume, error) {
+	output := make([]containerinstance.Volume, 0)
+
+	for _, v := range input {
+		volumeConfig := v.(map[string]interface{})
+
+		volume, err := expandContainerVolumeSource(volumeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		output = append(output, *volume)
+	}
+
+	return output, nil
+}
+
+func expandContainerVolumeSource(volumeConfig map[string]interface{}) (*containerinstance.Volume, error) {
+	name := volumeConfig["name"].(string)
+	shareName := volumeConfig["share_name"].(string)
+	storageAccountKey := volumeConfig["storage_account_key"].(string)
+	emptyDir := volumeConfig["empty_dir"].(bool)
+	gitRepos := volumeConfig["git_repo"].([]interface{})
+	secret := volumeConfig["secret"].(map[string]interface{})
+
+	if err := validateContainerVolumeSource(volumeConfig); err != nil {
+		return nil, err
+	}
+
+	if emptyDir {
+		return &containerinstance.Volume{
+			Name:     utils.String(name),
+			EmptyDir: map[string]interface{}{},
+		}, nil
+	}
+
+	if len(gitRepos) > 0 {
+		gitRepo := gitRepos[0].(map[string]interface{})
+		volume := containerinstance.Volume{
+			Name: utils.String(name),
+			GitRepo: &containerinstance.GitRepoVolume{
+				Repository: utils.String(gitRepo["url"].(string)),
+			},
+		}
+
+		if v := gitRepo["directory"].(string); v != "" {
+			volume.GitRepo.Directory = utils.String(v)
+		}
+		if v := gitRepo["revision"].(string); v != "" {
+			volume.GitRepo.Revision = utils.String(v)
+		}
+
+		return &volume, nil
+	}
+
+	if len(secret) > 0 {
+		// the API expects the contents of each file to be base64 encoded
+		files := make(map[string]*string)
+		for k, v := range secret {
+			files[k] = utils.String(base64.StdEncoding.EncodeToString([]byte(v.(string))))
+		}
+
+		return &containerinstance.Volume{
+			Name:   utils.String(name),
+			Secret: files,
+		}, nil
+	}
+
+	// the storage account key is usually interpolated from a Storage Account which may not exist at plan time,
+	// so whether it's been specified can only be checked once the values are known
+	if shareName == "" || storageAccountKey == "" {
+		return nil, fmt.Errorf("The volume %q must be an `empty_dir`, a `git_repo`, a `secret` or an Azure File share using `share_name` and `storage_account_key`", name)
+	}
+
+	storageAccountName, err := expandContainerVolumeStorageAccountName(volumeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &containerinstance.Volume{
+		Name: utils.String(name),
+		AzureFile: &containerinstance.AzureFileVolume{
+			ShareName:          utils.String(shareName),
+			StorageAccountName: utils.String(storageAccountName),
+			StorageAccountKey:  utils.String(storageAccountKey),
+		},
+	}, nil
+}
+
+func expandContainerVolumeStorageAccountName(volumeConfig map[string]interface{}) (string, error) {
+	name := volumeConfig["name"].(string)
+	storageAccountName := volumeConfig["storage_account_name"].(string)
+
+	storageAccountId := ""
+	if v, ok := volumeConfig["storage_account_id"]; ok {
+		storageAccountId = v.(string)
+	}
+
+	if storageAccountId == "" {
+		if storageAccountName == "" {
+			return "", fmt.Errorf("One of `storage_account_name` or `storage_account_id` must be specified for the volume %q", name)
+		}
+
+		return storageAccountName, nil
+	}
+
+	id, err := parseAzureResourceID(storageAccountId)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing `storage_account_id` for the volume %q: %+v", name, err)
+	}
+
+	accountName := id.Path["storageAccounts"]
+	if accountName == "" {
+		return "", fmt.Errorf("The `storage_account_id` for the volume %q is not a Storage Account ID: %q", name, storageAccountId)
+	}
+
+	if storageAccountName != "" && storageAccountName != accountName {
+		return "", fmt.Errorf("The `storage_account_name` %q for the volume %q doesn't match the `storage_account_id` %q", storageAccountName, name, storageAccountId)
+	}
+
+	return accountName, nil
+}
+
+func expandContainerVolumeMounts(input interface{}) []containerinstance.VolumeMount {
+	output := make([]containerinstance.VolumeMount, 0)
+
+	for _, v := range input.([]interface{}) {
+		mountConfig := v.(map[string]interface{})
+
+		output = append(output, containerinstance.VolumeMount{
+			Name:      utils.String(mountConfig["name"].(string)),
+			MountPath: utils.String(mountConfig["mount_path"].(string)),
+			ReadOnly:  utils.Bool(mountConfig["read_only"].(bool)),
+		})
+	}
+
+	return output
 }
 
 func expandContainerEnvironmentVariables(input interface{}) *[]containerinstance.EnvironmentVariable {
 	envVars := input.(map[string]interface{})
+	if len(envVars) == 0 {
+		return nil
+	}
+
 	output := make([]containerinstance.EnvironmentVariable, 0)
 
 	for k, v := range envVars {
@@ -666,11 +2086,11 @@ func flattenContainerImageRegistryCredentials(d *schema.ResourceData, input *[]c
 	return output
 }
 
-func expandContainerVolumes(input interface{}) (*[]containerinstance.VolumeMount, *[]containerinstance.Volume) {
+func expandContainerVolumes(input interface{}) (*[]containerinstance.VolumeMount, *[]containerinstance.Volume, error) {
 	volumesRaw := input.([]interface{})
 
 	if len(volumesRaw) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	volumeMounts := make([]containerinstance.VolumeMount, 0)
@@ -682,9 +2102,11 @@ func expandContainerVolumes(input interface{}) (*[]containerinstance.VolumeMount
 		name := volumeConfig["name"].(string)
 		mountPath := volumeConfig["mount_path"].(string)
 		readOnly := volumeConfig["read_only"].(bool)
-		shareName := volumeConfig["share_name"].(string)
-		storageAccountName := volumeConfig["storage_account_name"].(string)
-		storageAccountKey := volumeConfig["storage_account_key"].(string)
+
+		cv, err := expandContainerVolumeSource(volumeConfig)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		vm := containerinstance.VolumeMount{
 			Name:      utils.String(name),
@@ -694,18 +2116,327 @@ func expandContainerVolumes(input interface{}) (*[]containerinstance.VolumeMount
 
 		volumeMounts = append(volumeMounts, vm)
 
-		cv := containerinstance.Volume{
-			Name: utils.String(name),
-			AzureFile: &containerinstance.AzureFileVolume{
-				ShareName:          utils.String(shareName),
-				ReadOnly:           utils.Bool(readOnly),
-				StorageAccountName: utils.String(storageAccountName),
-				StorageAccountKey:  utils.String(storageAccountKey),
-			},
+		if cv.AzureFile != nil {
+			cv.AzureFile.ReadOnly = utils.Bool(readOnly)
 		}
 
-		containerGroupVolumes = append(containerGroupVolumes, cv)
+		containerGroupVolumes = append(containerGroupVolumes, *cv)
+	}
+
+	return &volumeMounts, &containerGroupVolumes, nil
+}
+
+func validateContainerGroupResourceLimits(containersConfig []interface{}) error {
+	for _, containerConfig := range containersConfig {
+		data := containerConfig.(map[string]interface{})
+		name := data["name"].(string)
+
+		if v, ok := data["cpu_limit"]; ok {
+			cpu := data["cpu"].(float64)
+			if cpuLimit := v.(float64); cpuLimit > 0 && cpuLimit < cpu {
+				return fmt.Errorf("The `cpu_limit` (%g) of container %q must not be lower than the `cpu` (%g)", cpuLimit, name, cpu)
+			}
+		}
+
+		if v, ok := data["memory_limit"]; ok {
+			memory := data["memory"].(float64)
+			if memoryLimit := v.(float64); memoryLimit > 0 && memoryLimit < memory {
+				return fmt.Errorf("The `memory_limit` (%g) of container %q must not be lower than the `memory` (%g)", memoryLimit, name, memory)
+			}
+		}
+	}
+
+	return nil
+}
+
+type containerGroupResourceMaximum struct {
+	CPU        float64
+	MemoryInGB float64
+}
+
+// containerGroupResourceMaximums are the documented maximum resources which can be requested
+// across all of the containers in a Container Group, keyed by the (lower-cased) OS Type
+var containerGroupResourceMaximums = map[string]containerGroupResourceMaximum{
+	"linux": {
+		CPU:        4,
+		MemoryInGB: 16,
+	},
+	"windows": {
+		CPU:        4,
+		MemoryInGB: 14,
+	},
+}
+
+func validateContainerGroupTotalResources(osType string, containersConfig []interface{}) error {
+	maximum, ok := containerGroupResourceMaximums[strings.ToLower(osType)]
+	if !ok {
+		return nil
+	}
+
+	totalCPU := 0.0
+	totalMemory := 0.0
+	for _, containerConfig := range containersConfig {
+		data := containerConfig.(map[string]interface{})
+		totalCPU += data["cpu"].(float64)
+		totalMemory += data["memory"].(float64)
+	}
+
+	if totalCPU > maximum.CPU {
+		return fmt.Errorf("The total `cpu` requested by the containers (%g) exceeds the maximum of %g for a %s Container Group", totalCPU, maximum.CPU, osType)
+	}
+
+	if totalMemory > maximum.MemoryInGB {
+		return fmt.Errorf("The total `memory` requested by the containers (%g GB) exceeds the maximum of %g GB for a %s Container Group", totalMemory, maximum.MemoryInGB, osType)
+	}
+
+	return nil
+}
+
+func validateContainerGroupExposedPorts(exposedPorts []interface{}, containersConfig []interface{}) error {
+	// the protocol defaults to TCP when it's not specified for a container port
+	openedPorts := make(map[string]bool)
+	for _, containerConfig := range containersConfig {
+		for _, v := range expandContainerPortsConfig(containerConfig.(map[string]interface{})) {
+			portConfig := v.(map[string]interface{})
+			protocol := strings.ToUpper(portConfig["protocol"].(string))
+			if protocol == "" {
+				protocol = string(containerinstance.TCP)
+			}
+			openedPorts[fmt.Sprintf("%d/%s", portConfig["port"].(int), protocol)] = true
+		}
+	}
+
+	for _, v := range exposedPorts {
+		exposedPort := v.(map[string]interface{})
+		port := exposedPort["port"].(int)
+		protocol := strings.ToUpper(exposedPort["protocol"].(string))
+
+		if !openedPorts[fmt.Sprintf("%d/%s", port, protocol)] {
+			return fmt.Errorf("The `exposed_port` %d (%s) isn't opened by any of the containers", port, protocol)
+		}
+	}
+
+	return nil
+}
+
+func validateContainerGroupVolumeSources(volumesConfig []interface{}, containersConfig []interface{}) error {
+	for _, v := range volumesConfig {
+		if err := validateContainerVolumeSource(v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	for _, containerConfig := range containersConfig {
+		data := containerConfig.(map[string]interface{})
+		if v, ok := data["volume"]; ok {
+			for _, volumeConfig := range v.([]interface{}) {
+				if err := validateContainerVolumeSource(volumeConfig.(map[string]interface{})); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateContainerVolumeSource(volumeConfig map[string]interface{}) error {
+	name := volumeConfig["name"].(string)
+	emptyDir := volumeConfig["empty_dir"].(bool)
+	gitRepos := volumeConfig["git_repo"].([]interface{})
+	secret := volumeConfig["secret"].(map[string]interface{})
+
+	azureFile := false
+	for _, key := range []string{"share_name", "storage_account_name", "storage_account_id", "storage_account_key", "storage_account_key_version"} {
+		if v, ok := volumeConfig[key]; ok && v.(string) != "" {
+			azureFile = true
+		}
+	}
+
+	sources := 0
+	for _, configured := range []bool{emptyDir, len(gitRepos) > 0, len(secret) > 0, azureFile} {
+		if configured {
+			sources++
+		}
+	}
+
+	if sources > 1 {
+		return fmt.Errorf("The volume %q must only be one of an `empty_dir`, a `git_repo`, a `secret` or an Azure File share (`share_name`, `storage_account_name`, `storage_account_id` and `storage_account_key`)", name)
+	}
+
+	return nil
+}
+
+func validateContainerGroupMountPaths(containersConfig []interface{}) error {
+	for _, containerConfig := range containersConfig {
+		data := containerConfig.(map[string]interface{})
+		name := data["name"].(string)
+
+		// both inline volumes and mounts of container group volumes share the container's filesystem
+		mounts := make([]interface{}, 0)
+		if v, ok := data["volume"]; ok {
+			mounts = append(mounts, v.([]interface{})...)
+		}
+		if v, ok := data["volume_mount"]; ok {
+			mounts = append(mounts, v.([]interface{})...)
+		}
+
+		mountPaths := make(map[string]string)
+		for _, mount := range mounts {
+			mountConfig := mount.(map[string]interface{})
+			volumeName := mountConfig["name"].(string)
+			mountPath := mountConfig["mount_path"].(string)
+			if mountPath == "" {
+				continue
+			}
+
+			if existing, ok := mountPaths[mountPath]; ok {
+				return fmt.Errorf("The volumes %q and %q in container %q are both mounted at %q - each volume must have a unique `mount_path`", existing, volumeName, name, mountPath)
+			}
+			mountPaths[mountPath] = volumeName
+		}
+	}
+
+	return nil
+}
+
+var containerGroupEnvVarNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func validateContainerGroupEnvironmentVariableNames(v interface{}, k string) (ws []string, errors []error) {
+	envVars := v.(map[string]interface{})
+
+	names := make([]string, 0, len(envVars))
+	for name := range envVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !containerGroupEnvVarNameRegex.MatchString(name) {
+			errors = append(errors, fmt.Errorf("%q contains an invalid environment variable name %q: names may only contain alphanumeric characters and underscores, and can't start with a digit", k, name))
+		}
+	}
+
+	return
+}
+
+// renderContainerGroupComposeYaml renders a best-effort docker-compose (v3) equivalent of the
+// flattened containers and volumes, for reference only - environment variable values and storage account keys
+// are redacted, since they commonly hold secrets
+func renderContainerGroupComposeYaml(containerConfigs []interface{}, volumeConfigs []interface{}) string {
+	var buf bytes.Buffer
+	volumes := make(map[string]map[string]interface{})
+
+	buf.WriteString("version: \"3\"\n")
+	buf.WriteString("services:\n")
+	for _, v := range containerConfigs {
+		container := v.(map[string]interface{})
+
+		buf.WriteString(fmt.Sprintf("  %s:\n", container["name"].(string)))
+		buf.WriteString(fmt.Sprintf("    image: %q\n", container["image"].(string)))
+
+		if commands, ok := container["commands"].([]string); ok && len(commands) > 0 {
+			quoted := make([]string, 0, len(commands))
+			for _, c := range commands {
+				quoted = append(quoted, fmt.Sprintf("%q", c))
+			}
+			buf.WriteString(fmt.Sprintf("    command: [%s]\n", strings.Join(quoted, ", ")))
+		}
+
+		if envVars, ok := container["environment_variables"].(map[string]interface{}); ok && len(envVars) > 0 {
+			keys := make([]string, 0, len(envVars))
+			for k := range envVars {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			buf.WriteString("    environment:\n")
+			for _, k := range keys {
+				buf.WriteString(fmt.Sprintf("      %s: \"<redacted>\"\n", k))
+			}
+		}
+
+		if ports, ok := container["ports"].([]interface{}); ok && len(ports) > 0 {
+			buf.WriteString("    ports:\n")
+			for _, p := range ports {
+				port := p.(map[string]interface{})
+				protocol := "tcp"
+				if v, ok := port["protocol"].(string); ok && v != "" {
+					protocol = strings.ToLower(v)
+				}
+				buf.WriteString(fmt.Sprintf("      - \"%d:%d/%s\"\n", port["port"].(int), port["port"].(int), protocol))
+			}
+		}
+
+		mounts := make([]string, 0)
+		for _, key := range []string{"volume", "volume_mount"} {
+			raw, ok := container[key].([]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, mv := range raw {
+				mount := mv.(map[string]interface{})
+				name := mount["name"].(string)
+				entry := fmt.Sprintf("%s:%s", name, mount["mount_path"].(string))
+				if readOnly, ok := mount["read_only"].(bool); ok && readOnly {
+					entry += ":ro"
+				}
+				mounts = append(mounts, entry)
+
+				if key == "volume" {
+					volumes[name] = mount
+				}
+			}
+		}
+
+		if len(mounts) > 0 {
+			buf.WriteString("    volumes:\n")
+			for _, m := range mounts {
+				buf.WriteString(fmt.Sprintf("      - %q\n", m))
+			}
+		}
+
+		cpu, _ := container["cpu"].(float64)
+		memory, _ := container["memory"].(float64)
+		buf.WriteString("    deploy:\n")
+		buf.WriteString("      resources:\n")
+		buf.WriteString("        reservations:\n")
+		buf.WriteString(fmt.Sprintf("          cpus: \"%s\"\n", strconv.FormatFloat(cpu, 'f', -1, 64)))
+		buf.WriteString(fmt.Sprintf("          memory: \"%sG\"\n", strconv.FormatFloat(memory, 'f', -1, 64)))
+	}
+
+	for _, v := range volumeConfigs {
+		volume := v.(map[string]interface{})
+		volumes[volume["name"].(string)] = volume
+	}
+
+	if len(volumes) > 0 {
+		names := make([]string, 0, len(volumes))
+		for name := range volumes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		buf.WriteString("volumes:\n")
+		for _, name := range names {
+			volume := volumes[name]
+			// only Azure File shares have a compose volume driver
+			if shareName, _ := volume["share_name"].(string); shareName == "" {
+				buf.WriteString(fmt.Sprintf("  %s: {}\n", name))
+				continue
+			}
+
+			buf.WriteString(fmt.Sprintf("  %s:\n", name))
+			buf.WriteString("    driver: azure_file\n")
+			buf.WriteString("    driver_opts:\n")
+			buf.WriteString(fmt.Sprintf("      share_name: %q\n", volume["share_name"].(string)))
+			if accountName, ok := volume["storage_account_name"].(string); ok {
+				buf.WriteString(fmt.Sprintf("      storage_account_name: %q\n", accountName))
+			}
+			buf.WriteString("      storage_account_key: \"<redacted>\"\n")
+		}
 	}
 
-	return &volumeMounts, &containerGroupVolumes
+	return buf.String()
 }
@@ -1,16 +1,1482 @@
 package azurerm
 
 import (
+	"bytes"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/containerinstance/mgmt/2018-04-01/containerinstance"
+	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/date"
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/utils"
 )
 
+func TestAzureRMContainerGroupExposedPorts_flatten(t *testing.T) {
+	cases := []struct {
+		Input    *[]containerinstance.Port
+		Expected []map[string]interface{}
+	}{
+		{
+			Input:    nil,
+			Expected: []map[string]interface{}{},
+		},
+		{
+			Input: &[]containerinstance.Port{
+				{Port: utils.Int32(80), Protocol: containerinstance.TCP},
+				{Port: utils.Int32(53), Protocol: containerinstance.UDP},
+			},
+			Expected: []map[string]interface{}{
+				{"port": 53, "protocol": "UDP"},
+				{"port": 80, "protocol": "TCP"},
+			},
+		},
+		{
+			Input: &[]containerinstance.Port{
+				{Port: utils.Int32(53), Protocol: containerinstance.UDP},
+				{Port: utils.Int32(53), Protocol: containerinstance.TCP},
+			},
+			Expected: []map[string]interface{}{
+				{"port": 53, "protocol": "TCP"},
+				{"port": 53, "protocol": "UDP"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		output := flattenContainerGroupExposedPorts(tc.Input)
+		if len(output) != len(tc.Expected) {
+			t.Fatalf("Expected %d exposed ports but got %d", len(tc.Expected), len(output))
+		}
+
+		for i, v := range output {
+			port := v.(map[string]interface{})
+			if port["port"] != tc.Expected[i]["port"] || port["protocol"] != tc.Expected[i]["protocol"] {
+				t.Fatalf("Expected exposed port %d to be %+v but got %+v", i, tc.Expected[i], port)
+			}
+		}
+	}
+}
+
+func TestAzureRMContainerGroupExposedPorts_validation(t *testing.T) {
+	containers := []interface{}{
+		map[string]interface{}{
+			"name": "web",
+			"ports": []interface{}{
+				map[string]interface{}{"port": 80, "protocol": ""},
+				map[string]interface{}{"port": 53, "protocol": "udp"},
+			},
+		},
+		map[string]interface{}{
+			"name":     "legacy",
+			"ports":    []interface{}{},
+			"port":     8080,
+			"protocol": "TCP",
+		},
+	}
+
+	cases := []struct {
+		Port     int
+		Protocol string
+		ErrCount int
+	}{
+		{80, "TCP", 0},
+		{80, "tcp", 0},
+		{53, "UDP", 0},
+		{8080, "TCP", 0},
+		{53, "TCP", 1},
+		{80, "UDP", 1},
+		{443, "TCP", 1},
+	}
+
+	for _, tc := range cases {
+		exposedPorts := []interface{}{
+			map[string]interface{}{"port": tc.Port, "protocol": tc.Protocol},
+		}
+
+		err := validateContainerGroupExposedPorts(exposedPorts, containers)
+		errCount := 0
+		if err != nil {
+			errCount = 1
+		}
+
+		if errCount != tc.ErrCount {
+			t.Fatalf("Expected %d errors for %d (%s) but got: %+v", tc.ErrCount, tc.Port, tc.Protocol, err)
+		}
+	}
+
+	lower := resourceArmContainerGroupExposedPortHash(map[string]interface{}{"port": 80, "protocol": "tcp"})
+	upper := resourceArmContainerGroupExposedPortHash(map[string]interface{}{"port": 80, "protocol": "TCP"})
+	if lower != upper {
+		t.Fatalf("Expected the exposed port hash to ignore the casing of the protocol")
+	}
+}
+
+func TestAzureRMContainerGroupExposedPorts_diff(t *testing.T) {
+	cases := []struct {
+		ExposedPort int
+		ExpectError bool
+	}{
+		{ExposedPort: 80, ExpectError: false},
+		{ExposedPort: 9090, ExpectError: true},
+	}
+
+	for _, tc := range cases {
+		raw, err := config.NewRawConfig(map[string]interface{}{
+			"name":                "acctestcontainergroup",
+			"location":            "westeurope",
+			"resource_group_name": "acctestRG",
+			"os_type":             "Linux",
+			"exposed_port": []interface{}{
+				map[string]interface{}{"port": tc.ExposedPort, "protocol": "TCP"},
+			},
+			"container": []interface{}{
+				map[string]interface{}{
+					"name":   "hw",
+					"image":  "microsoft/aci-helloworld:latest",
+					"cpu":    0.5,
+					"memory": 0.5,
+					"ports": []interface{}{
+						map[string]interface{}{"port": 80},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Error building the config: %+v", err)
+		}
+
+		// the plan (rather than the apply) is expected to fail
+		_, err = resourceArmContainerGroup().Diff(nil, terraform.NewResourceConfig(raw), nil)
+		if tc.ExpectError && (err == nil || !strings.Contains(err.Error(), "isn't opened by any of the containers")) {
+			t.Fatalf("Expected the plan to fail for the `exposed_port` %d but got: %+v", tc.ExposedPort, err)
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Expected the plan to succeed for the `exposed_port` %d but got: %+v", tc.ExposedPort, err)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupInstanceView_flatten(t *testing.T) {
+	startTime, err := time.Parse(time.RFC3339, "2018-07-01T10:30:00Z")
+	if err != nil {
+		t.Fatalf("Error parsing time: %+v", err)
+	}
+
+	cases := []struct {
+		Input    *containerinstance.ContainerPropertiesInstanceView
+		Expected map[string]interface{}
+	}{
+		{
+			Input: nil,
+			Expected: map[string]interface{}{
+				"current_state": "",
+				"restart_count": 0,
+				"start_time":    "",
+				"exit_code":     0,
+				"events":        []interface{}{},
+			},
+		},
+		{
+			Input: &containerinstance.ContainerPropertiesInstanceView{
+				RestartCount: utils.Int32(3),
+				CurrentState: &containerinstance.ContainerState{
+					State:     utils.String("Terminated"),
+					StartTime: &date.Time{Time: startTime},
+					ExitCode:  utils.Int32(137),
+				},
+				Events: &[]containerinstance.Event{
+					{
+						Name:           utils.String("Failed"),
+						Message:        utils.String("Failed to pull image \"microsoft/aci-helloworld:missing\""),
+						Count:          utils.Int32(2),
+						FirstTimestamp: &date.Time{Time: startTime},
+						LastTimestamp:  &date.Time{Time: startTime.Add(time.Minute)},
+						Type:           utils.String("Warning"),
+					},
+				},
+			},
+			Expected: map[string]interface{}{
+				"current_state": "Terminated",
+				"restart_count": 3,
+				"start_time":    "2018-07-01T10:30:00Z",
+				"exit_code":     137,
+				"events": []interface{}{
+					map[string]interface{}{
+						"name":            "Failed",
+						"message":         "Failed to pull image \"microsoft/aci-helloworld:missing\"",
+						"count":           2,
+						"first_timestamp": "2018-07-01T10:30:00Z",
+						"last_timestamp":  "2018-07-01T10:31:00Z",
+						"type":            "Warning",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		actual := make(map[string]interface{})
+		flattenContainerInstanceView(actual, tc.Input)
+
+		if !reflect.DeepEqual(actual, tc.Expected) {
+			t.Fatalf("Expected %+v but got %+v", tc.Expected, actual)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupEndpoints_flatten(t *testing.T) {
+	ports := &[]containerinstance.Port{
+		{Port: utils.Int32(443), Protocol: containerinstance.TCP},
+		{Port: utils.Int32(53), Protocol: containerinstance.UDP},
+	}
+
+	cases := []struct {
+		Input    *containerinstance.IPAddress
+		Expected []string
+	}{
+		{
+			Input:    nil,
+			Expected: []string{},
+		},
+		{
+			Input: &containerinstance.IPAddress{
+				Ports: ports,
+			},
+			Expected: []string{},
+		},
+		{
+			Input: &containerinstance.IPAddress{
+				IP:    utils.String("10.0.0.4"),
+				Ports: ports,
+			},
+			Expected: []string{"10.0.0.4:53", "10.0.0.4:443"},
+		},
+		{
+			Input: &containerinstance.IPAddress{
+				IP:    utils.String("10.0.0.4"),
+				Fqdn:  utils.String("acctest.westeurope.azurecontainer.io"),
+				Ports: ports,
+			},
+			Expected: []string{"acctest.westeurope.azurecontainer.io:53", "acctest.westeurope.azurecontainer.io:443"},
+		},
+		{
+			Input: &containerinstance.IPAddress{
+				IP:   utils.String("10.0.0.4"),
+				Fqdn: utils.String("acctest.westeurope.azurecontainer.io"),
+			},
+			Expected: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		actual := flattenContainerGroupEndpoints(tc.Input)
+		if len(actual) != len(tc.Expected) {
+			t.Fatalf("Expected %d endpoints but got %d: %+v", len(tc.Expected), len(actual), actual)
+		}
+
+		for i, expected := range tc.Expected {
+			endpoint := actual[i].(map[string]interface{})
+			if endpoint["endpoint"] != expected {
+				t.Fatalf("Expected endpoint %d to be %q but got %q", i, expected, endpoint["endpoint"])
+			}
+		}
+	}
+
+	udp := flattenContainerGroupEndpoints(&containerinstance.IPAddress{IP: utils.String("10.0.0.4"), Ports: ports})[0].(map[string]interface{})
+	if udp["port"] != 53 || udp["protocol"] != "UDP" {
+		t.Fatalf("Expected the first endpoint to be port 53/UDP but got %+v", udp)
+	}
+}
+
+func TestAzureRMContainerGroupRetryableError(t *testing.T) {
+	responseError := func(statusCode int) error {
+		return autorest.DetailedError{
+			Original: &azureautorest.RequestError{
+				DetailedError: autorest.DetailedError{
+					StatusCode: statusCode,
+				},
+			},
+			StatusCode: autorest.UndefinedStatusCode,
+		}
+	}
+
+	cases := []struct {
+		Error    error
+		Expected bool
+	}{
+		{Error: fmt.Errorf("something went wrong"), Expected: false},
+		{Error: responseError(http.StatusBadRequest), Expected: false},
+		{Error: responseError(http.StatusConflict), Expected: false},
+		{Error: responseError(http.StatusTooManyRequests), Expected: true},
+		{Error: responseError(http.StatusInternalServerError), Expected: true},
+		{Error: responseError(http.StatusServiceUnavailable), Expected: true},
+		{Error: autorest.DetailedError{StatusCode: http.StatusServiceUnavailable}, Expected: true},
+	}
+
+	for _, tc := range cases {
+		if actual := isContainerGroupRetryableError(tc.Error); actual != tc.Expected {
+			t.Fatalf("Expected %t for %+v but got %t", tc.Expected, tc.Error, actual)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupQuotaError(t *testing.T) {
+	serviceError := func(statusCode int, code string, message string) error {
+		return autorest.DetailedError{
+			Original: &azureautorest.RequestError{
+				DetailedError: autorest.DetailedError{
+					StatusCode: statusCode,
+				},
+				ServiceError: &azureautorest.ServiceError{
+					Code:    code,
+					Message: message,
+				},
+			},
+			StatusCode: autorest.UndefinedStatusCode,
+		}
+	}
+
+	cases := []struct {
+		Error    error
+		Expected bool
+	}{
+		{
+			Error:    nil,
+			Expected: false,
+		},
+		{
+			Error:    serviceError(http.StatusConflict, "ContainerGroupQuotaReached", "Resource type 'Microsoft.ContainerInstance/containerGroups' container group quota 'StandardCores' exceeded in region 'westeurope'. Limit: '100', Usage: '100', Requested: '1'."),
+			Expected: true,
+		},
+		{
+			Error:    serviceError(http.StatusConflict, "StandardCoresQuotaExceeded", "The requested resource is not available in the location 'westeurope'."),
+			Expected: true,
+		},
+		{
+			Error:    &azureautorest.ServiceError{Code: "StandardCoresQuotaReached"},
+			Expected: true,
+		},
+		{
+			Error:    serviceError(http.StatusBadRequest, "InvalidImageName", "The image 'microsoft/aci-helloworld:' is not valid."),
+			Expected: false,
+		},
+		{
+			Error:    serviceError(http.StatusServiceUnavailable, "ServiceUnavailable", "The Container Group 'quota-worker' could not be provisioned, please retry."),
+			Expected: false,
+		},
+		{
+			Error:    fmt.Errorf(`Code="ContainerGroupQuotaReached" Message="container group quota exceeded"`),
+			Expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		if actual := isContainerGroupQuotaError(tc.Error); actual != tc.Expected {
+			t.Fatalf("Expected %t for %+v but got %t", tc.Expected, tc.Error, actual)
+		}
+	}
+
+	usages := &[]containerinstance.Usage{
+		{
+			Name:         &containerinstance.UsageName{Value: utils.String("StandardCores"), LocalizedValue: utils.String("Standard Cores")},
+			CurrentValue: utils.Int32(100),
+			Limit:        utils.Int32(100),
+		},
+		{
+			Name:         &containerinstance.UsageName{Value: utils.String("ContainerGroups")},
+			CurrentValue: utils.Int32(12),
+			Limit:        utils.Int32(100),
+		},
+		{
+			Name: &containerinstance.UsageName{Value: utils.String("Incomplete")},
+		},
+	}
+
+	expected := "ContainerGroups: 12 of 100, Standard Cores: 100 of 100"
+	if actual := formatContainerGroupUsages(usages); actual != expected {
+		t.Fatalf("Expected the usages to be formatted as %q but got %q", expected, actual)
+	}
+
+	if actual := formatContainerGroupUsages(nil); actual != "no usage information available" {
+		t.Fatalf("Expected no usage information but got %q", actual)
+	}
+}
+
+func TestAzureRMContainerGroupProvisioningState(t *testing.T) {
+	cases := []struct {
+		Input    *containerinstance.ContainerGroupProperties
+		Expected string
+	}{
+		{
+			Input:    nil,
+			Expected: "Pending",
+		},
+		{
+			Input:    &containerinstance.ContainerGroupProperties{},
+			Expected: "Pending",
+		},
+		{
+			Input:    &containerinstance.ContainerGroupProperties{ProvisioningState: utils.String("")},
+			Expected: "Pending",
+		},
+		{
+			Input:    &containerinstance.ContainerGroupProperties{ProvisioningState: utils.String("Creating")},
+			Expected: "Creating",
+		},
+		{
+			Input:    &containerinstance.ContainerGroupProperties{ProvisioningState: utils.String("Succeeded")},
+			Expected: "Succeeded",
+		},
+	}
+
+	for _, tc := range cases {
+		if actual := containerGroupProvisioningState(tc.Input); actual != tc.Expected {
+			t.Fatalf("Expected the provisioning state %q but got %q", tc.Expected, actual)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupInstanceState(t *testing.T) {
+	terminatedContainer := func(exitCode int32) containerinstance.Container {
+		return containerinstance.Container{
+			Name: utils.String("batch"),
+			ContainerProperties: &containerinstance.ContainerProperties{
+				InstanceView: &containerinstance.ContainerPropertiesInstanceView{
+					CurrentState: &containerinstance.ContainerState{
+						State:    utils.String("Terminated"),
+						ExitCode: utils.Int32(exitCode),
+					},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		Name     string
+		Input    containerinstance.ContainerGroupProperties
+		Expected string
+	}{
+		{
+			Name:     "Provisioning",
+			Input:    containerinstance.ContainerGroupProperties{},
+			Expected: "",
+		},
+		{
+			Name: "Running",
+			Input: containerinstance.ContainerGroupProperties{
+				RestartPolicy: containerinstance.Never,
+				InstanceView: &containerinstance.ContainerGroupPropertiesInstanceView{
+					State: utils.String("Running"),
+				},
+				Containers: &[]containerinstance.Container{
+					{
+						Name: utils.String("batch"),
+						ContainerProperties: &containerinstance.ContainerProperties{
+							InstanceView: &containerinstance.ContainerPropertiesInstanceView{
+								CurrentState: &containerinstance.ContainerState{
+									State: utils.String("Running"),
+								},
+							},
+						},
+					},
+				},
+			},
+			Expected: "Running",
+		},
+		{
+			Name: "Completed",
+			Input: containerinstance.ContainerGroupProperties{
+				RestartPolicy: containerinstance.Never,
+				InstanceView: &containerinstance.ContainerGroupPropertiesInstanceView{
+					State: utils.String("Succeeded"),
+				},
+				Containers: &[]containerinstance.Container{terminatedContainer(0), terminatedContainer(0)},
+			},
+			Expected: "Completed",
+		},
+		{
+			Name: "Failed",
+			Input: containerinstance.ContainerGroupProperties{
+				RestartPolicy: containerinstance.OnFailure,
+				InstanceView: &containerinstance.ContainerGroupPropertiesInstanceView{
+					State: utils.String("Failed"),
+				},
+				Containers: &[]containerinstance.Container{terminatedContainer(0), terminatedContainer(137)},
+			},
+			Expected: "Failed",
+		},
+		{
+			Name: "Restarting",
+			Input: containerinstance.ContainerGroupProperties{
+				RestartPolicy: containerinstance.Always,
+				InstanceView: &containerinstance.ContainerGroupPropertiesInstanceView{
+					State: utils.String("Running"),
+				},
+				Containers: &[]containerinstance.Container{terminatedContainer(0)},
+			},
+			Expected: "Running",
+		},
+	}
+
+	for _, tc := range cases {
+		if state := containerGroupInstanceState(&tc.Input); state != tc.Expected {
+			t.Fatalf("Expected the state for %q to be %q but got %q", tc.Name, tc.Expected, state)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupIsTerminal(t *testing.T) {
+	cases := []struct {
+		RestartPolicy containerinstance.ContainerGroupRestartPolicy
+		State         string
+		Expected      bool
+	}{
+		{RestartPolicy: containerinstance.OnFailure, State: "", Expected: false},
+		{RestartPolicy: containerinstance.OnFailure, State: "Running", Expected: false},
+		{RestartPolicy: containerinstance.OnFailure, State: "Completed", Expected: true},
+		{RestartPolicy: containerinstance.OnFailure, State: "Failed", Expected: false},
+		{RestartPolicy: containerinstance.Never, State: "Completed", Expected: true},
+		{RestartPolicy: containerinstance.Never, State: "Failed", Expected: true},
+		{RestartPolicy: containerinstance.Always, State: "Running", Expected: false},
+	}
+
+	for _, tc := range cases {
+		if terminal := containerGroupIsTerminal(tc.RestartPolicy, tc.State); terminal != tc.Expected {
+			t.Fatalf("Expected a %q group in state %q to have is_terminal %t but got %t", tc.RestartPolicy, tc.State, tc.Expected, terminal)
+		}
+	}
+
+	props := containerinstance.ContainerGroupProperties{
+		RestartPolicy: containerinstance.OnFailure,
+		Containers: &[]containerinstance.Container{
+			{
+				Name: utils.String("batch"),
+				ContainerProperties: &containerinstance.ContainerProperties{
+					InstanceView: &containerinstance.ContainerPropertiesInstanceView{
+						CurrentState: &containerinstance.ContainerState{
+							State:    utils.String("Terminated"),
+							ExitCode: utils.Int32(0),
+						},
+					},
+				},
+			},
+		},
+	}
+	if !containerGroupIsTerminal(props.RestartPolicy, containerGroupInstanceState(&props)) {
+		t.Fatalf("Expected an OnFailure group whose containers all exited 0 to be terminal")
+	}
+}
+
+func TestAzureRMContainerGroupSharedVolumes_expand(t *testing.T) {
+	sharedVolume := map[string]interface{}{
+		"name":                 "logs",
+		"share_name":           "acctestss",
+		"storage_account_name": "acctestsa",
+		"storage_account_key":  "secret",
+	}
+	container := func(name string, mounts ...map[string]interface{}) map[string]interface{} {
+		volumeMounts := make([]interface{}, 0)
+		for _, m := range mounts {
+			volumeMounts = append(volumeMounts, m)
+		}
+
+		return map[string]interface{}{
+			"name":         name,
+			"image":        "microsoft/aci-helloworld:latest",
+			"cpu":          0.5,
+			"memory":       0.5,
+			"volume_mount": volumeMounts,
+		}
+	}
+
+	raw := map[string]interface{}{
+		"volume": []interface{}{sharedVolume},
+		"container": []interface{}{
+			container("hw", map[string]interface{}{"name": "logs", "mount_path": "/aci/logs"}),
+			container("sidecar", map[string]interface{}{"name": "logs", "mount_path": "/var/logs", "read_only": true}),
+		},
+	}
+	d := schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, raw)
+
+	containers, _, volumes, err := expandContainerGroupContainers(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	if len(*volumes) != 1 || *(*volumes)[0].Name != "logs" || *(*volumes)[0].AzureFile.ShareName != "acctestss" {
+		t.Fatalf("Expected a single shared `logs` volume but got %+v", *volumes)
+	}
+
+	for i, expectedPath := range []string{"/aci/logs", "/var/logs"} {
+		mounts := (*containers)[i].VolumeMounts
+		if mounts == nil || len(*mounts) != 1 {
+			t.Fatalf("Expected container %d to have a single volume mount", i)
+		}
+
+		if *(*mounts)[0].Name != "logs" || *(*mounts)[0].MountPath != expectedPath {
+			t.Fatalf("Expected container %d to mount `logs` at %q but got %q at %q", i, expectedPath, *(*mounts)[0].Name, *(*mounts)[0].MountPath)
+		}
+	}
+
+	raw["container"] = []interface{}{
+		container("hw", map[string]interface{}{"name": "missing", "mount_path": "/aci/logs"}),
+	}
+	d = schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, raw)
+	if _, _, _, err := expandContainerGroupContainers(d); err == nil {
+		t.Fatalf("Expected an error for a `volume_mount` referencing an undefined volume")
+	}
+}
+
+func TestAzureRMContainerGroupOmittedBlocks_expand(t *testing.T) {
+	raw := map[string]interface{}{
+		"container": []interface{}{
+			map[string]interface{}{
+				"name":    "hw",
+				"image":   "microsoft/aci-helloworld:latest",
+				"cpu":     0.5,
+				"memory":  0.5,
+				"command": "/bin/bash -c ls",
+			},
+		},
+	}
+	d := schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, raw)
+
+	containers, ports, volumes, err := expandContainerGroupContainers(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	if ports != nil {
+		t.Fatalf("Expected no container group ports but got %+v", *ports)
+	}
+
+	if volumes != nil {
+		t.Fatalf("Expected no container group volumes but got %+v", *volumes)
+	}
+
+	container := (*containers)[0]
+	if container.Ports != nil {
+		t.Fatalf("Expected no container ports but got %+v", *container.Ports)
+	}
+
+	if container.VolumeMounts != nil {
+		t.Fatalf("Expected no volume mounts but got %+v", *container.VolumeMounts)
+	}
+
+	if container.EnvironmentVariables != nil {
+		t.Fatalf("Expected no environment variables but got %+v", *container.EnvironmentVariables)
+	}
+
+	if container.Command == nil || strings.Join(*container.Command, " ") != "/bin/bash -c ls" {
+		t.Fatalf("Expected the `command` to be used when `commands` is empty but got %+v", container.Command)
+	}
+
+	flattened := flattenContainerGroupContainers(d, containers, ports, volumes)[0].(map[string]interface{})
+	if _, ok := flattened["volume"]; ok {
+		t.Fatalf("Expected no `volume` to be flattened but got %+v", flattened["volume"])
+	}
+
+	if _, ok := flattened["environment_variables"]; ok {
+		t.Fatalf("Expected no `environment_variables` to be flattened but got %+v", flattened["environment_variables"])
+	}
+
+	if mounts := flattened["volume_mount"].([]interface{}); len(mounts) != 0 {
+		t.Fatalf("Expected no `volume_mount` to be flattened but got %+v", mounts)
+	}
+}
+
+func TestAzureRMContainerGroupFqdnChange_validation(t *testing.T) {
+	cases := []struct {
+		OldLabel        string
+		NewLabel        string
+		OldLocation     string
+		NewLocation     string
+		AllowFqdnChange bool
+		ErrCount        int
+	}{
+		{"acctest", "acctest", "westeurope", "West Europe", false, 0},
+		{"", "acctest", "westeurope", "westeurope", false, 0},
+		{"acctest", "acctest2", "westeurope", "westeurope", false, 1},
+		{"acctest", "", "westeurope", "westeurope", false, 1},
+		{"acctest", "acctest", "westeurope", "northeurope", false, 1},
+		{"acctest", "acctest2", "westeurope", "westeurope", true, 0},
+		{"acctest", "acctest", "westeurope", "northeurope", true, 0},
+	}
+
+	for _, tc := range cases {
+		err := validateContainerGroupFqdnChange(tc.OldLabel, tc.NewLabel, tc.OldLocation, tc.NewLocation, tc.AllowFqdnChange)
+		errCount := 0
+		if err != nil {
+			errCount = 1
+		}
+
+		if errCount != tc.ErrCount {
+			t.Fatalf("Expected %d errors changing %q/%q to %q/%q (allow_fqdn_change = %t) but got: %+v", tc.ErrCount, tc.OldLabel, tc.OldLocation, tc.NewLabel, tc.NewLocation, tc.AllowFqdnChange, err)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupMountPaths_validation(t *testing.T) {
+	volume := func(name string, mountPath string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":       name,
+			"mount_path": mountPath,
+		}
+	}
+
+	cases := []struct {
+		Volumes      []interface{}
+		VolumeMounts []interface{}
+		ErrCount     int
+	}{
+		{
+			Volumes:  []interface{}{volume("logs", "/aci/logs"), volume("data", "/aci/data")},
+			ErrCount: 0,
+		},
+		{
+			Volumes:  []interface{}{volume("logs", "/aci/logs"), volume("data", "/aci/logs")},
+			ErrCount: 1,
+		},
+		{
+			Volumes:      []interface{}{volume("logs", "/aci/logs")},
+			VolumeMounts: []interface{}{volume("shared", "/aci/shared")},
+			ErrCount:     0,
+		},
+		{
+			Volumes:      []interface{}{volume("logs", "/aci/logs")},
+			VolumeMounts: []interface{}{volume("shared", "/aci/logs")},
+			ErrCount:     1,
+		},
+	}
+
+	for _, tc := range cases {
+		containers := []interface{}{
+			map[string]interface{}{
+				"name":   "hw",
+				"volume": tc.Volumes,
+			},
+			// the same mount path in another container is fine
+			map[string]interface{}{
+				"name":   "sidecar",
+				"volume": []interface{}{volume("logs", "/aci/logs")},
+			},
+		}
+		if tc.VolumeMounts != nil {
+			containers[0].(map[string]interface{})["volume_mount"] = tc.VolumeMounts
+		}
+
+		err := validateContainerGroupMountPaths(containers)
+		errCount := 0
+		if err != nil {
+			errCount = 1
+		}
+
+		if errCount != tc.ErrCount {
+			t.Fatalf("Expected %d errors for %+v but got: %+v", tc.ErrCount, containers[0], err)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupVolumeStorageAccountName_expand(t *testing.T) {
+	storageAccountId := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/acctestRG/providers/Microsoft.Storage/storageAccounts/acctestsa"
+
+	cases := []struct {
+		StorageAccountName string
+		StorageAccountId   string
+		Expected           string
+		ExpectError        bool
+	}{
+		{
+			StorageAccountName: "acctestsa",
+			Expected:           "acctestsa",
+		},
+		{
+			StorageAccountId: storageAccountId,
+			Expected:         "acctestsa",
+		},
+		{
+			StorageAccountName: "acctestsa",
+			StorageAccountId:   storageAccountId,
+			Expected:           "acctestsa",
+		},
+		{
+			StorageAccountName: "othersa",
+			StorageAccountId:   storageAccountId,
+			ExpectError:        true,
+		},
+		{
+			StorageAccountId: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/acctestRG",
+			ExpectError:      true,
+		},
+		{
+			ExpectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		volumeConfig := map[string]interface{}{
+			"name":                 "logs",
+			"storage_account_name": tc.StorageAccountName,
+			"storage_account_id":   tc.StorageAccountId,
+		}
+
+		actual, err := expandContainerVolumeStorageAccountName(volumeConfig)
+		if tc.ExpectError {
+			if err == nil {
+				t.Fatalf("Expected an error for %+v but got %q", volumeConfig, actual)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Expected no error for %+v but got: %+v", volumeConfig, err)
+		}
+
+		if actual != tc.Expected {
+			t.Fatalf("Expected the storage account name %q but got %q", tc.Expected, actual)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupTotalResources(t *testing.T) {
+	// each case is a group of two identical containers
+	cases := []struct {
+		OSType   string
+		CPU      float64
+		Memory   float64
+		ErrCount int
+	}{
+		{OSType: "Linux", CPU: 0.5, Memory: 1.5, ErrCount: 0},
+		{OSType: "Linux", CPU: 2, Memory: 8, ErrCount: 0},
+		{OSType: "linux", CPU: 2.5, Memory: 1.5, ErrCount: 1},
+		{OSType: "Linux", CPU: 1, Memory: 8.5, ErrCount: 1},
+		{OSType: "Windows", CPU: 2, Memory: 7, ErrCount: 0},
+		{OSType: "Windows", CPU: 2, Memory: 7.5, ErrCount: 1},
+		{OSType: "windows", CPU: 3, Memory: 1, ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		container := map[string]interface{}{
+			"name":   "hw",
+			"image":  "microsoft/aci-helloworld:latest",
+			"cpu":    tc.CPU,
+			"memory": tc.Memory,
+		}
+
+		err := validateContainerGroupTotalResources(tc.OSType, []interface{}{container, container})
+		errCount := 0
+		if err != nil {
+			errCount = 1
+		}
+
+		if errCount != tc.ErrCount {
+			t.Fatalf("Expected %d errors for 2 %s containers with %v CPU and %vGB memory but got: %+v", tc.ErrCount, tc.OSType, tc.CPU, tc.Memory, err)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupResourceLimits(t *testing.T) {
+	container := func(cpuLimit float64, memoryLimit float64) map[string]interface{} {
+		return map[string]interface{}{
+			"name":         "hw",
+			"image":        "microsoft/aci-helloworld:latest",
+			"cpu":          1.0,
+			"memory":       1.5,
+			"cpu_limit":    cpuLimit,
+			"memory_limit": memoryLimit,
+		}
+	}
+
+	cases := []struct {
+		Container map[string]interface{}
+		ErrCount  int
+	}{
+		{container(0, 0), 0},
+		{container(2.0, 0), 0},
+		{container(1.0, 1.5), 0},
+		{container(0.5, 0), 1},
+		{container(0, 1.0), 1},
+	}
+
+	for _, tc := range cases {
+		err := validateContainerGroupResourceLimits([]interface{}{tc.Container})
+		errCount := 0
+		if err != nil {
+			errCount = 1
+		}
+
+		if errCount != tc.ErrCount {
+			t.Fatalf("Expected %d errors for %+v but got: %+v", tc.ErrCount, tc.Container, err)
+		}
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, map[string]interface{}{
+		"container": []interface{}{container(0, 0)},
+	})
+	containers, _, _, err := expandContainerGroupContainers(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	if limits := (*containers)[0].Resources.Limits; limits != nil {
+		t.Fatalf("Expected no limits to be sent when omitted but got %+v", *limits)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, map[string]interface{}{
+		"container": []interface{}{container(2.0, 0)},
+	})
+	containers, _, _, err = expandContainerGroupContainers(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	limits := (*containers)[0].Resources.Limits
+	if limits == nil || limits.CPU == nil || *limits.CPU != 2.0 || limits.MemoryInGB != nil {
+		t.Fatalf("Expected only a CPU limit of 2.0 but got %+v", limits)
+	}
+
+	flattened := flattenContainerGroupContainers(d, containers, nil, nil)[0].(map[string]interface{})
+	if flattened["cpu_limit"] != 2.0 {
+		t.Fatalf("Expected the `cpu_limit` to be flattened as 2.0 but got %+v", flattened["cpu_limit"])
+	}
+
+	if _, ok := flattened["memory_limit"]; ok {
+		t.Fatalf("Expected no `memory_limit` to be flattened but got %+v", flattened["memory_limit"])
+	}
+}
+
+// testAzureRMContainerGroupVolumeConfig returns the config of a volume without a source, with the overrides applied
+func testAzureRMContainerGroupVolumeConfig(overrides map[string]interface{}) map[string]interface{} {
+	config := map[string]interface{}{
+		"name":                 "scratch",
+		"empty_dir":            false,
+		"share_name":           "",
+		"storage_account_name": "",
+		"storage_account_id":   "",
+		"storage_account_key":  "",
+		"git_repo":             []interface{}{},
+		"secret":               map[string]interface{}{},
+	}
+	for k, v := range overrides {
+		config[k] = v
+	}
+	return config
+}
+
+func TestAzureRMContainerGroupVolumeSources_validation(t *testing.T) {
+	gitRepo := []interface{}{
+		map[string]interface{}{
+			"url": "https://github.com/Azure-Samples/aci-helloworld",
+		},
+	}
+
+	cases := []struct {
+		Volume   map[string]interface{}
+		ErrCount int
+	}{
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"empty_dir": true}), 0},
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"git_repo": gitRepo}), 0},
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"share_name": "acctestshare", "storage_account_name": "acctestsa"}), 0},
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"empty_dir": true, "share_name": "acctestshare"}), 1},
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"empty_dir": true, "storage_account_key": "bXlrZXk="}), 1},
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"empty_dir": true, "storage_account_key_version": "2018-07-01"}), 1},
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"git_repo": gitRepo, "storage_account_name": "acctestsa"}), 1},
+		{testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"git_repo": gitRepo, "empty_dir": true}), 1},
+	}
+
+	for _, tc := range cases {
+		// the volume is validated both at the group level and inline within a container
+		containers := []interface{}{
+			map[string]interface{}{
+				"name":   "hw",
+				"volume": []interface{}{tc.Volume},
+			},
+		}
+
+		for _, err := range []error{
+			validateContainerGroupVolumeSources([]interface{}{tc.Volume}, []interface{}{}),
+			validateContainerGroupVolumeSources([]interface{}{}, containers),
+		} {
+			errCount := 0
+			if err != nil {
+				errCount = 1
+			}
+
+			if errCount != tc.ErrCount {
+				t.Fatalf("Expected %d errors for %+v but got: %+v", tc.ErrCount, tc.Volume, err)
+			}
+		}
+	}
+}
+
+func TestAzureRMContainerGroupVolumeSource_expand(t *testing.T) {
+	gitRepo := []interface{}{
+		map[string]interface{}{
+			"url":       "https://github.com/Azure-Samples/aci-helloworld",
+			"directory": ".",
+			"revision":  "",
+		},
+	}
+
+	cases := []struct {
+		Config      map[string]interface{}
+		ExpectError bool
+		EmptyDir    bool
+		GitRepo     bool
+	}{
+		{
+			Config:   testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"empty_dir": true}),
+			EmptyDir: true,
+		},
+		{
+			Config:  testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"git_repo": gitRepo}),
+			GitRepo: true,
+		},
+		{
+			Config:      testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"git_repo": gitRepo, "empty_dir": true}),
+			ExpectError: true,
+		},
+		{
+			Config:      testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"git_repo": gitRepo, "share_name": "acctestss", "storage_account_name": "acctestsa", "storage_account_key": "secret"}),
+			ExpectError: true,
+		},
+		{
+			Config: testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"share_name": "acctestss", "storage_account_name": "acctestsa", "storage_account_key": "secret"}),
+		},
+		{
+			Config:      testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"empty_dir": true, "share_name": "acctestss"}),
+			ExpectError: true,
+		},
+		{
+			Config:      testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"storage_account_name": "acctestsa", "storage_account_key": "secret"}),
+			ExpectError: true,
+		},
+		{
+			Config:      testAzureRMContainerGroupVolumeConfig(map[string]interface{}{}),
+			ExpectError: true,
+		},
+	}
+
+	secret := map[string]interface{}{
+		"tls.crt": "certificate",
+	}
+
+	actual, err := expandContainerVolumeSource(testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"secret": secret}))
+	if err != nil {
+		t.Fatalf("Expected no error for a `secret` volume but got: %+v", err)
+	}
+
+	if actual.Secret == nil || *actual.Secret["tls.crt"] != "Y2VydGlmaWNhdGU=" {
+		t.Fatalf("Expected the `secret` file to be base64 encoded but got %+v", actual.Secret)
+	}
+
+	// the API doesn't return the contents of the files
+	flattened := make(map[string]interface{})
+	flattenContainerVolumeSource(flattened, containerinstance.Volume{Secret: map[string]*string{"tls.crt": nil}}, map[string]interface{}{"secret": secret})
+	if flattened["secret"].(map[string]interface{})["tls.crt"] != "certificate" {
+		t.Fatalf("Expected the `secret` to be flattened from the config but got %+v", flattened["secret"])
+	}
+
+	// the storage account key version is only held in the config
+	azureFile := containerinstance.Volume{
+		AzureFile: &containerinstance.AzureFileVolume{
+			ShareName:          utils.String("acctestshare"),
+			StorageAccountName: utils.String("acctestsa"),
+		},
+	}
+	flattened = make(map[string]interface{})
+	flattenContainerVolumeSource(flattened, azureFile, map[string]interface{}{"storage_account_key": "bXlrZXk=", "storage_account_key_version": "2018-07-01"})
+	if flattened["storage_account_key"] != "bXlrZXk=" || flattened["storage_account_key_version"] != "2018-07-01" {
+		t.Fatalf("Expected the `storage_account_key` and `storage_account_key_version` to be flattened from the config but got %+v", flattened)
+	}
+
+	if _, err := expandContainerVolumeSource(testAzureRMContainerGroupVolumeConfig(map[string]interface{}{"secret": secret, "empty_dir": true})); err == nil {
+		t.Fatalf("Expected an error for a volume with both `secret` and `empty_dir`")
+	}
+
+	for _, tc := range cases {
+		actual, err := expandContainerVolumeSource(tc.Config)
+		if tc.ExpectError {
+			if err == nil {
+				t.Fatalf("Expected an error for %+v but got %+v", tc.Config, actual)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Expected no error for %+v but got: %+v", tc.Config, err)
+		}
+
+		azureFile := !tc.EmptyDir && !tc.GitRepo
+		if (actual.EmptyDir != nil) != tc.EmptyDir || (actual.GitRepo != nil) != tc.GitRepo || (actual.AzureFile != nil) != azureFile {
+			t.Fatalf("Expected an empty_dir (%t), git_repo (%t) or Azure File (%t) volume but got %+v", tc.EmptyDir, tc.GitRepo, azureFile, actual)
+		}
+
+		flattened := make(map[string]interface{})
+		flattenContainerVolumeSource(flattened, *actual, tc.Config)
+		if flattened["empty_dir"] != tc.EmptyDir {
+			t.Fatalf("Expected `empty_dir` to be flattened as %t but got %+v", tc.EmptyDir, flattened["empty_dir"])
+		}
+
+		gitRepos := flattened["git_repo"].([]interface{})
+		if tc.GitRepo {
+			if len(gitRepos) != 1 {
+				t.Fatalf("Expected a single `git_repo` to be flattened but got %+v", gitRepos)
+			}
+
+			repo := gitRepos[0].(map[string]interface{})
+			if repo["url"] != "https://github.com/Azure-Samples/aci-helloworld" || repo["directory"] != "." {
+				t.Fatalf("Expected the `git_repo` to round-trip but got %+v", repo)
+			}
+
+			if _, ok := repo["revision"]; ok {
+				t.Fatalf("Expected no `revision` to be flattened but got %+v", repo["revision"])
+			}
+		} else if len(gitRepos) != 0 {
+			t.Fatalf("Expected no `git_repo` to be flattened but got %+v", gitRepos)
+		}
+
+		if azureFile && (flattened["share_name"] != "acctestss" || flattened["storage_account_key"] != "secret") {
+			t.Fatalf("Expected the Azure File fields to be flattened but got %+v", flattened)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupPorts(t *testing.T) {
+	container := func(extra map[string]interface{}) map[string]interface{} {
+		config := map[string]interface{}{
+			"name":   "hw",
+			"image":  "microsoft/aci-helloworld:latest",
+			"cpu":    0.5,
+			"memory": 0.5,
+		}
+		for k, v := range extra {
+			config[k] = v
+		}
+		return config
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, map[string]interface{}{
+		"container": []interface{}{
+			container(map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"port": 8080, "protocol": "tcp"},
+					map[string]interface{}{"port": 9090, "protocol": "udp"},
+				},
+			}),
+		},
+	})
+
+	containers, containerGroupPorts, _, err := expandContainerGroupContainers(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	if len(*(*containers)[0].Ports) != 2 || len(*containerGroupPorts) != 2 {
+		t.Fatalf("Expected 2 container and container group ports but got %+v and %+v", *(*containers)[0].Ports, *containerGroupPorts)
+	}
+
+	if (*containerGroupPorts)[1].Protocol != containerinstance.UDP {
+		t.Fatalf("Expected the second container group port to be UDP but got %q", (*containerGroupPorts)[1].Protocol)
+	}
+
+	// the protocol is correlated from the container group ports when the container port doesn't include it
+	ports := flattenContainerPorts(&[]containerinstance.ContainerPort{
+		{Port: utils.Int32(8080)},
+		{Port: utils.Int32(9090)},
+	}, containerGroupPorts)
+	expected := []map[string]interface{}{
+		{"port": 8080, "protocol": "TCP"},
+		{"port": 9090, "protocol": "UDP"},
+	}
+	if len(ports) != len(expected) {
+		t.Fatalf("Expected %d ports but got %+v", len(expected), ports)
+	}
+	for i, v := range ports {
+		port := v.(map[string]interface{})
+		if port["port"] != expected[i]["port"] || port["protocol"] != expected[i]["protocol"] {
+			t.Fatalf("Expected port %d to be %+v but got %+v", i, expected[i], port)
+		}
+	}
+
+	flattened := flattenContainerGroupContainers(d, containers, containerGroupPorts, nil)[0].(map[string]interface{})
+	if len(flattened["ports"].([]interface{})) != 2 || flattened["port"] != int32(8080) || flattened["protocol"] != "TCP" {
+		t.Fatalf("Expected both `ports` and the deprecated `port` to be flattened but got %+v", flattened)
+	}
+
+	// the deprecated `port` and `protocol` are still supported
+	d = schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, map[string]interface{}{
+		"container": []interface{}{
+			container(map[string]interface{}{
+				"port":     80,
+				"protocol": "udp",
+			}),
+		},
+	})
+
+	containers, containerGroupPorts, _, err = expandContainerGroupContainers(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	if len(*(*containers)[0].Ports) != 1 || *(*containerGroupPorts)[0].Port != 80 || (*containerGroupPorts)[0].Protocol != containerinstance.UDP {
+		t.Fatalf("Expected a single UDP port 80 but got %+v", *containerGroupPorts)
+	}
+}
+
+func TestAzureRMContainerGroup_expand(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, map[string]interface{}{
+		"name":           "acctestcontainergroup",
+		"location":       "West Europe",
+		"os_type":        "linux",
+		"dns_name_label": "acctestlabel",
+		"container": []interface{}{
+			map[string]interface{}{
+				"name":   "hw",
+				"image":  "mine.acr.io/aci-helloworld:latest",
+				"cpu":    0.5,
+				"memory": 0.5,
+			},
+		},
+		"image_registry_credential": []interface{}{
+			map[string]interface{}{
+				"server":   "mine.acr.io",
+				"username": "acrusername",
+				"password": "rotatedpassword",
+			},
+		},
+		"tags": map[string]interface{}{
+			"environment": "Testing",
+		},
+	})
+
+	containerGroup, err := expandContainerGroup(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	if *containerGroup.Location != "westeurope" || *containerGroup.Tags["environment"] != "Testing" {
+		t.Fatalf("Expected the location and tags to be set but got %+v", containerGroup)
+	}
+
+	props := containerGroup.ContainerGroupProperties
+	if *props.IPAddress.DNSNameLabel != "acctestlabel" || props.RestartPolicy != containerinstance.Always {
+		t.Fatalf("Expected the DNS Name Label and default restart policy to be set but got %+v", props)
+	}
+
+	creds := props.ImageRegistryCredentials
+	if creds == nil || len(*creds) != 1 || *(*creds)[0].Password != "rotatedpassword" {
+		t.Fatalf("Expected the image registry credential to be sent but got %+v", creds)
+	}
+}
+
+func TestAzureRMContainerGroupCommands(t *testing.T) {
+	commands := []interface{}{"/bin/sh", "-c", "echo hello world"}
+	d := schema.TestResourceDataRaw(t, resourceArmContainerGroup().Schema, map[string]interface{}{
+		"container": []interface{}{
+			map[string]interface{}{
+				"name":     "hw",
+				"image":    "microsoft/aci-helloworld:latest",
+				"cpu":      0.5,
+				"memory":   0.5,
+				"commands": commands,
+			},
+		},
+	})
+
+	containers, ports, volumes, err := expandContainerGroupContainers(d)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+
+	command := (*containers)[0].Command
+	if command == nil || len(*command) != 3 || (*command)[2] != "echo hello world" {
+		t.Fatalf("Expected each of the `commands` to be sent as a single argument but got %+v", command)
+	}
+
+	flattened := flattenContainerGroupContainers(d, containers, ports, volumes)[0].(map[string]interface{})
+	actual := flattened["commands"].([]string)
+	if len(actual) != len(commands) {
+		t.Fatalf("Expected %d `commands` but got %+v", len(commands), actual)
+	}
+
+	for i, v := range commands {
+		if actual[i] != v.(string) {
+			t.Fatalf("Expected command %d to be %q but got %q", i, v.(string), actual[i])
+		}
+	}
+}
+
+func TestAzureRMContainerGroupFqdn(t *testing.T) {
+	cases := []struct {
+		Fqdn           string
+		DNSNameLabel   string
+		Location       string
+		ExpectedRegion string
+		ExpectedMatch  bool
+	}{
+		{
+			Fqdn:           "acctest.westeurope.azurecontainer.io",
+			DNSNameLabel:   "acctest",
+			Location:       "West Europe",
+			ExpectedRegion: "westeurope",
+			ExpectedMatch:  true,
+		},
+		{
+			Fqdn:           "acctest.westeurope.azurecontainer.io",
+			DNSNameLabel:   "acctest",
+			Location:       "westeurope",
+			ExpectedRegion: "westeurope",
+			ExpectedMatch:  true,
+		},
+		{
+			Fqdn:           "acctest.euwest.azurecontainer.io",
+			DNSNameLabel:   "acctest",
+			Location:       "West Europe",
+			ExpectedRegion: "euwest",
+			ExpectedMatch:  false,
+		},
+		{
+			Fqdn:           "other.westeurope.azurecontainer.io",
+			DNSNameLabel:   "acctest",
+			Location:       "westeurope",
+			ExpectedRegion: "westeurope",
+			ExpectedMatch:  false,
+		},
+	}
+
+	for _, tc := range cases {
+		if region := containerGroupFqdnRegion(tc.Fqdn); region != tc.ExpectedRegion {
+			t.Fatalf("Expected the region for %q to be %q but got %q", tc.Fqdn, tc.ExpectedRegion, region)
+		}
+
+		if match := containerGroupFqdnMatches(tc.Fqdn, tc.DNSNameLabel, tc.Location); match != tc.ExpectedMatch {
+			t.Fatalf("Expected %q matching label %q in %q to be %t but got %t", tc.Fqdn, tc.DNSNameLabel, tc.Location, tc.ExpectedMatch, match)
+		}
+
+		address := &containerinstance.IPAddress{
+			Fqdn:         utils.String(tc.Fqdn),
+			DNSNameLabel: utils.String(tc.DNSNameLabel),
+		}
+		warned := testAzureRMContainerGroupLogsWarning(func() {
+			warnOnContainerGroupFqdnMismatch("acctestcg", "acctestRG", address, utils.String(tc.Location))
+		})
+		if warned == tc.ExpectedMatch {
+			t.Fatalf("Expected a warning for %q with label %q in %q to be %t but got %t", tc.Fqdn, tc.DNSNameLabel, tc.Location, !tc.ExpectedMatch, warned)
+		}
+	}
+
+	// there's nothing to compare when the Container Group has no FQDN
+	warned := testAzureRMContainerGroupLogsWarning(func() {
+		warnOnContainerGroupFqdnMismatch("acctestcg", "acctestRG", &containerinstance.IPAddress{}, utils.String("westeurope"))
+	})
+	if warned {
+		t.Fatalf("Expected no warning for a Container Group without an FQDN")
+	}
+}
+
+func testAzureRMContainerGroupLogsWarning(f func()) bool {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	f()
+	return strings.Contains(buf.String(), "[WARN]")
+}
+
+func TestAzureRMContainerGroupComposeYaml(t *testing.T) {
+	containers := []interface{}{
+		map[string]interface{}{
+			"name":   "hf",
+			"image":  "seanmckenna/aci-hellofiles",
+			"cpu":    1.0,
+			"memory": 1.5,
+			"ports": []interface{}{
+				map[string]interface{}{"port": 80, "protocol": "TCP"},
+			},
+			"commands": []string{"/bin/bash", "-c", "ls"},
+			"environment_variables": map[string]interface{}{
+				"foo":      "bar",
+				"PASSWORD": "supersecretvalue",
+			},
+			"volume": []interface{}{
+				map[string]interface{}{
+					"name":                 "logs",
+					"mount_path":           "/aci/logs",
+					"read_only":            true,
+					"share_name":           "acctestss",
+					"storage_account_name": "acctestsa",
+					"storage_account_key":  "supersecretkey",
+				},
+			},
+		},
+		map[string]interface{}{
+			"name":   "sidecar",
+			"image":  "microsoft/aci-tutorial-sidecar",
+			"cpu":    0.5,
+			"memory": 0.5,
+		},
+	}
+
+	output := renderContainerGroupComposeYaml(containers, []interface{}{})
+
+	expected := []string{
+		"services:\n  hf:\n    image: \"seanmckenna/aci-hellofiles\"\n",
+		"    command: [\"/bin/bash\", \"-c\", \"ls\"]\n",
+		"    environment:\n      PASSWORD: \"<redacted>\"\n      foo: \"<redacted>\"\n",
+		"      - \"80:80/tcp\"\n",
+		"      - \"logs:/aci/logs:ro\"\n",
+		"  sidecar:\n    image: \"microsoft/aci-tutorial-sidecar\"\n",
+		"volumes:\n  logs:\n",
+		"      storage_account_key: \"<redacted>\"\n",
+	}
+	for _, v := range expected {
+		if !strings.Contains(output, v) {
+			t.Fatalf("Expected the rendered compose YAML to contain %q but got:\n%s", v, output)
+		}
+	}
+
+	for _, secret := range []string{"supersecretkey", "supersecretvalue", "bar"} {
+		if strings.Contains(output, secret) {
+			t.Fatalf("Expected %q to be redacted but got:\n%s", secret, output)
+		}
+	}
+}
+
+func TestAzureRMContainerGroupEnvironmentVariableNames_validation(t *testing.T) {
+	cases := []struct {
+		Value    map[string]interface{}
+		ErrCount int
+	}{
+		{Value: map[string]interface{}{}, ErrCount: 0},
+		{Value: map[string]interface{}{"NODE_ENV": "testing", "foo1": "bar", "_private": "1"}, ErrCount: 0},
+		{Value: map[string]interface{}{"NODE ENV": "testing"}, ErrCount: 1},
+		{Value: map[string]interface{}{"1FOO": "bar"}, ErrCount: 1},
+		{Value: map[string]interface{}{"FOO-BAR": "baz", "1FOO": "bar"}, ErrCount: 2},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateContainerGroupEnvironmentVariableNames(tc.Value, "environment_variables")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for %+v but got %d", tc.ErrCount, tc.Value, len(errors))
+		}
+	}
+}
+
 func TestAccAzureRMContainerGroup_imageRegistryCredentials(t *testing.T) {
 	resourceName := "azurerm_container_group.test"
 	ri := acctest.RandInt()
@@ -104,6 +1570,8 @@ func TestAccAzureRMContainerGroup_linuxBasic(t *testing.T) {
 					testCheckAzureRMContainerGroupExists(resourceName),
 					resource.TestCheckResourceAttr(resourceName, "container.#", "1"),
 					resource.TestCheckResourceAttr(resourceName, "os_type", "Linux"),
+					resource.TestCheckResourceAttr(resourceName, "endpoints.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "endpoints.0.port", "80"),
 				),
 			},
 			{
@@ -119,6 +1587,65 @@ func TestAccAzureRMContainerGroup_linuxBasic(t *testing.T) {
 	})
 }
 
+func TestAccAzureRMContainerGroup_linuxUpdateTags(t *testing.T) {
+	resourceName := "azurerm_container_group.test"
+	ri := acctest.RandInt()
+	location := testLocation()
+
+	var startTime string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMContainerGroup_linuxTags(ri, location, "Testing"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerGroupExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "tags.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "tags.environment", "Testing"),
+					testCheckAzureRMContainerGroupStartTime(resourceName, &startTime),
+				),
+			},
+			{
+				Config: testAccAzureRMContainerGroup_linuxTags(ri, location, "Production"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerGroupExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "tags.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "tags.environment", "Production"),
+					testCheckAzureRMContainerGroupNotRecreated(resourceName, &startTime),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMContainerGroup_linuxExposedPort(t *testing.T) {
+	resourceName := "azurerm_container_group.test"
+	ri := acctest.RandInt()
+
+	config := testAccAzureRMContainerGroup_linuxExposedPort(ri, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerGroupExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "exposed_port.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "exposed_ports.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "exposed_ports.0.port", "80"),
+					resource.TestCheckResourceAttr(resourceName, "container.0.ports.#", "2"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccAzureRMContainerGroup_linuxBasicUpdate(t *testing.T) {
 	resourceName := "azurerm_container_group.test"
 	ri := acctest.RandInt()
@@ -193,6 +1720,67 @@ func TestAccAzureRMContainerGroup_linuxComplete(t *testing.T) {
 	})
 }
 
+func TestAccAzureRMContainerGroup_linuxSharedVolume(t *testing.T) {
+	resourceName := "azurerm_container_group.test"
+	ri := acctest.RandInt()
+	rs := acctest.RandString(5)
+
+	config := testAccAzureRMContainerGroup_linuxSharedVolume(ri, rs, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerGroupExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "volume.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "volume.0.name", "logs"),
+					resource.TestCheckResourceAttr(resourceName, "container.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "container.0.volume_mount.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "container.0.volume_mount.0.mount_path", "/aci/logs"),
+					resource.TestCheckResourceAttr(resourceName, "container.1.volume_mount.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "container.1.volume_mount.0.read_only", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMContainerGroup_dnsNameLabelChange(t *testing.T) {
+	resourceName := "azurerm_container_group.test"
+	ri := acctest.RandInt()
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMContainerGroup_dnsNameLabel(ri, location, "first", false),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerGroupExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "dns_name_label", fmt.Sprintf("acctestcg-first-%d", ri)),
+				),
+			},
+			{
+				Config:      testAccAzureRMContainerGroup_dnsNameLabel(ri, location, "second", false),
+				ExpectError: regexp.MustCompile("allow_fqdn_change"),
+			},
+			{
+				Config: testAccAzureRMContainerGroup_dnsNameLabel(ri, location, "second", true),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerGroupExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "dns_name_label", fmt.Sprintf("acctestcg-second-%d", ri)),
+				),
+			},
+		},
+	})
+}
+
 func TestAccAzureRMContainerGroup_windowsBasic(t *testing.T) {
 	resourceName := "azurerm_container_group.test"
 	ri := acctest.RandInt()
@@ -546,6 +2134,197 @@ resource "azurerm_container_group" "test" {
 `, ri, location, ri, ri, ri, ri)
 }
 
+func testAccAzureRMContainerGroup_linuxSharedVolume(ri int, rs string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "accsa%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_share" "test" {
+  name                 = "acctestss-%d"
+  resource_group_name  = "${azurerm_resource_group.test.name}"
+  storage_account_name = "${azurerm_storage_account.test.name}"
+  quota                = 50
+}
+
+resource "azurerm_container_group" "test" {
+  name                = "acctestcontainergroup-%d"
+  location            = "${azurerm_resource_group.test.location}"
+  resource_group_name = "${azurerm_resource_group.test.name}"
+  ip_address_type     = "public"
+  os_type             = "linux"
+
+  volume {
+    name                 = "logs"
+    share_name           = "${azurerm_storage_share.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_account_key  = "${azurerm_storage_account.test.primary_access_key}"
+  }
+
+  container {
+    name   = "hf"
+    image  = "seanmckenna/aci-hellofiles"
+    cpu    = "0.5"
+    memory = "0.5"
+    port   = "80"
+
+    volume_mount {
+      name       = "logs"
+      mount_path = "/aci/logs"
+    }
+  }
+
+  container {
+    name   = "sidecar"
+    image  = "microsoft/aci-tutorial-sidecar"
+    cpu    = "0.5"
+    memory = "0.5"
+
+    volume_mount {
+      name       = "logs"
+      mount_path = "/aci/logs"
+      read_only  = true
+    }
+  }
+}
+`, ri, location, rs, ri, ri)
+}
+
+func testAccAzureRMContainerGroup_dnsNameLabel(ri int, location string, label string, allowFqdnChange bool) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_container_group" "test" {
+  name                = "acctestcontainergroup-%d"
+  location            = "${azurerm_resource_group.test.location}"
+  resource_group_name = "${azurerm_resource_group.test.name}"
+  ip_address_type     = "public"
+  dns_name_label      = "acctestcg-%s-%d"
+  allow_fqdn_change   = %t
+  os_type             = "linux"
+
+  container {
+    name   = "hw"
+    image  = "microsoft/aci-helloworld:latest"
+    cpu    = "0.5"
+    memory = "0.5"
+    port   = "80"
+  }
+}
+`, ri, location, ri, label, ri, allowFqdnChange)
+}
+
+func testAccAzureRMContainerGroup_linuxTags(ri int, location string, environment string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_container_group" "test" {
+  name                = "acctestcontainergroup-%d"
+  location            = "${azurerm_resource_group.test.location}"
+  resource_group_name = "${azurerm_resource_group.test.name}"
+  ip_address_type     = "public"
+  os_type             = "linux"
+
+  container {
+    name   = "hw"
+    image  = "microsoft/aci-helloworld:latest"
+    cpu    = "0.5"
+    memory = "0.5"
+    port   = "80"
+  }
+
+  tags {
+    environment = "%s"
+  }
+}
+`, ri, location, ri, environment)
+}
+
+func testAccAzureRMContainerGroup_linuxExposedPort(ri int, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_container_group" "test" {
+  name                = "acctestcontainergroup-%d"
+  location            = "${azurerm_resource_group.test.location}"
+  resource_group_name = "${azurerm_resource_group.test.name}"
+  ip_address_type     = "public"
+  os_type             = "linux"
+
+  exposed_port {
+    port     = 80
+    protocol = "TCP"
+  }
+
+  container {
+    name   = "hw"
+    image  = "microsoft/aci-helloworld:latest"
+    cpu    = "0.5"
+    memory = "0.5"
+
+    ports {
+      port = 80
+    }
+
+    ports {
+      port = 8080
+    }
+  }
+}
+`, ri, location, ri)
+}
+
+// testCheckAzureRMContainerGroupStartTime records when the first container started, since the ID of a
+// recreated Container Group is the same but its containers are started again
+func testCheckAzureRMContainerGroupStartTime(name string, startTime *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		*startTime = rs.Primary.Attributes["container.0.start_time"]
+		if *startTime == "" {
+			return fmt.Errorf("Bad: no start time is available for the first container in Container Group %q", rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMContainerGroupNotRecreated(name string, startTime *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		if actual := rs.Primary.Attributes["container.0.start_time"]; actual != *startTime {
+			return fmt.Errorf("Bad: expected Container Group %q to be updated in-place but its containers were restarted at %q (previously %q)", rs.Primary.ID, actual, *startTime)
+		}
+
+		return nil
+	}
+}
+
 func testCheckAzureRMContainerGroupExists(name string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		// Ensure we have enough information in state to look up in API
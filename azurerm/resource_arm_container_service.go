@@ -1,8 +1,11 @@
 package azurerm
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"regexp"
+	"strings"
 
 	"time"
 
@@ -12,6 +15,8 @@ import (
 	"github.com/hashicorp/terraform/helper/hashcode"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/azure"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/utils"
 )
 
@@ -21,6 +26,16 @@ func resourceArmContainerService() *schema.Resource {
 		Read:   resourceArmContainerServiceRead,
 		Update: resourceArmContainerServiceCreate,
 		Delete: resourceArmContainerServiceDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -40,6 +55,13 @@ func resourceArmContainerService() *schema.Resource {
 				ValidateFunc: validateArmContainerServiceOrchestrationPlatform,
 			},
 
+			"orchestration_version": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validateArmContainerServiceOrchestrationVersion,
+			},
+
 			"master_profile": {
 				Type:     schema.TypeSet,
 				Required: true,
@@ -54,8 +76,9 @@ func resourceArmContainerService() *schema.Resource {
 						},
 
 						"dns_prefix": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArmContainerServiceDNSPrefix,
 						},
 
 						"fqdn": {
@@ -95,10 +118,31 @@ func resourceArmContainerService() *schema.Resource {
 				Set: resourceAzureRMContainerServiceLinuxProfilesHash,
 			},
 
+			"windows_profile": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"admin_username": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"admin_password": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Sensitive:    true,
+							ValidateFunc: validateArmContainerServiceWindowsAdminPassword,
+						},
+					},
+				},
+				Set: resourceAzureRMContainerServiceWindowsProfilesHash,
+			},
+
 			"agent_pool_profile": {
 				Type:     schema.TypeSet,
 				Required: true,
-				MaxItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"name": {
@@ -115,9 +159,10 @@ func resourceArmContainerService() *schema.Resource {
 						},
 
 						"dns_prefix": {
-							Type:     schema.TypeString,
-							Required: true,
-							ForceNew: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validateArmContainerServiceDNSPrefix,
 						},
 
 						"fqdn": {
@@ -130,6 +175,34 @@ func resourceArmContainerService() *schema.Resource {
 							Required:         true,
 							DiffSuppressFunc: ignoreCaseDiffSuppressFunc,
 						},
+
+						// 0 (or unset) uses the default OS Disk size for the VM Size
+						"os_disk_size_gb": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.IntBetween(0, 1023),
+						},
+
+						"vnet_subnet_id": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ForceNew:     true,
+							ValidateFunc: azure.ValidateResourceID,
+						},
+
+						"os_type": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							ForceNew:         true,
+							Default:          string(containerservice.Linux),
+							DiffSuppressFunc: ignoreCaseDiffSuppressFunc,
+							ValidateFunc: validation.StringInSlice([]string{
+								string(containerservice.Linux),
+								string(containerservice.Windows),
+							}, true),
+						},
 					},
 				},
 				Set: resourceAzureRMContainerServiceAgentPoolProfilesHash,
@@ -158,7 +231,8 @@ func resourceArmContainerService() *schema.Resource {
 
 			"diagnostics_profile": {
 				Type:     schema.TypeSet,
-				Required: true,
+				Optional: true,
+				Computed: true,
 				MaxItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
@@ -215,6 +289,22 @@ func resourceArmContainerServiceCreate(d *schema.ResourceData, meta interface{})
 		Tags: expandTags(tags),
 	}
 
+	if v, ok := d.GetOk("orchestration_version"); ok {
+		orchestrationVersion := v.(string)
+		parameters.Properties.OrchestratorProfile.OrchestratorVersion = &orchestrationVersion
+	}
+
+	windowsProfile := expandAzureRmContainerServiceWindowsProfile(d)
+	if windowsProfile != nil {
+		parameters.WindowsProfile = windowsProfile
+	}
+
+	for _, profile := range agentProfiles {
+		if profile.OsType == containerservice.Windows && windowsProfile == nil {
+			return fmt.Errorf("A `windows_profile` block must be specified when an `agent_pool_profile` has an `os_type` of `Windows`")
+		}
+	}
+
 	servicePrincipalProfile := expandAzureRmContainerServiceServicePrincipal(d)
 	if servicePrincipalProfile != nil {
 		parameters.ServicePrincipalProfile = servicePrincipalProfile
@@ -235,12 +325,18 @@ func resourceArmContainerServiceCreate(d *schema.ResourceData, meta interface{})
 		return fmt.Errorf("Cannot read Container Service %s (resource group %s) ID", name, resGroup)
 	}
 
+	// this function is used for both creating and updating the Container Service
+	timeout := d.Timeout(schema.TimeoutCreate)
+	if d.Id() != "" {
+		timeout = d.Timeout(schema.TimeoutUpdate)
+	}
+
 	log.Printf("[DEBUG] Waiting for Container Service (%s) to become available", d.Get("name"))
 	stateConf := &resource.StateChangeConf{
 		Pending:    []string{"Updating", "Creating"},
 		Target:     []string{"Succeeded"},
 		Refresh:    containerServiceStateRefreshFunc(client, resGroup, name),
-		Timeout:    30 * time.Minute,
+		Timeout:    timeout,
 		MinTimeout: 15 * time.Second,
 	}
 	if _, err := stateConf.WaitForState(); err != nil {
@@ -262,7 +358,10 @@ func resourceArmContainerServiceRead(d *schema.ResourceData, meta interface{}) e
 	resGroup := id.ResourceGroup
 	name := id.Path["containerServices"]
 
-	ctx := meta.(*ArmClient).StopContext
+	// bound the request, since it can otherwise hang indefinitely against a slow region
+	ctx, cancel := context.WithTimeout(meta.(*ArmClient).StopContext, d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
 	resp, err := containerServiceClient.Get(ctx, resGroup, name)
 	if err != nil {
 		if utils.ResponseWasNotFound(resp.Response) {
@@ -279,25 +378,41 @@ func resourceArmContainerServiceRead(d *schema.ResourceData, meta interface{}) e
 		d.Set("location", azureRMNormalizeLocation(*location))
 	}
 
-	d.Set("orchestration_platform", string(resp.Properties.OrchestratorProfile.OrchestratorType))
+	if props := resp.Properties; props != nil {
+		if orchestrator := props.OrchestratorProfile; orchestrator != nil {
+			d.Set("orchestration_platform", string(orchestrator.OrchestratorType))
+			d.Set("orchestration_version", orchestrator.OrchestratorVersion)
+		}
 
-	masterProfiles := flattenAzureRmContainerServiceMasterProfile(*resp.Properties.MasterProfile)
-	d.Set("master_profile", &masterProfiles)
+		if props.MasterProfile != nil {
+			masterProfiles := flattenAzureRmContainerServiceMasterProfile(*props.MasterProfile)
+			d.Set("master_profile", masterProfiles)
+		}
+
+		if props.LinuxProfile != nil {
+			linuxProfile := flattenAzureRmContainerServiceLinuxProfile(*props.LinuxProfile)
+			d.Set("linux_profile", linuxProfile)
+		}
 
-	linuxProfile := flattenAzureRmContainerServiceLinuxProfile(*resp.Properties.LinuxProfile)
-	d.Set("linux_profile", &linuxProfile)
+		windowsProfile := flattenAzureRmContainerServiceWindowsProfile(d, props.WindowsProfile)
+		if windowsProfile != nil {
+			d.Set("windows_profile", windowsProfile)
+		}
 
-	agentPoolProfiles := flattenAzureRmContainerServiceAgentPoolProfiles(resp.Properties.AgentPoolProfiles)
-	d.Set("agent_pool_profile", &agentPoolProfiles)
+		if props.AgentPoolProfiles != nil {
+			agentPoolProfiles := flattenAzureRmContainerServiceAgentPoolProfiles(props.AgentPoolProfiles)
+			d.Set("agent_pool_profile", agentPoolProfiles)
+		}
 
-	servicePrincipal := flattenAzureRmContainerServiceServicePrincipalProfile(resp.Properties.ServicePrincipalProfile)
-	if servicePrincipal != nil {
-		d.Set("service_principal", servicePrincipal)
-	}
+		servicePrincipal := flattenAzureRmContainerServiceServicePrincipalProfile(d, props.ServicePrincipalProfile)
+		if servicePrincipal != nil {
+			d.Set("service_principal", servicePrincipal)
+		}
 
-	diagnosticProfile := flattenAzureRmContainerServiceDiagnosticsProfile(resp.Properties.DiagnosticsProfile)
-	if diagnosticProfile != nil {
-		d.Set("diagnostics_profile", diagnosticProfile)
+		diagnosticProfile := flattenAzureRmContainerServiceDiagnosticsProfile(props.DiagnosticsProfile)
+		if diagnosticProfile != nil {
+			d.Set("diagnostics_profile", diagnosticProfile)
+		}
 	}
 
 	flattenAndSetTags(d, resp.Tags)
@@ -316,7 +431,9 @@ func resourceArmContainerServiceDelete(d *schema.ResourceData, meta interface{})
 	resGroup := id.ResourceGroup
 	name := id.Path["containerServices"]
 
-	ctx := meta.(*ArmClient).StopContext
+	ctx, cancel := context.WithTimeout(meta.(*ArmClient).StopContext, d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
 	future, err := containerServiceClient.Delete(ctx, resGroup, name)
 
 	if err != nil {
@@ -369,6 +486,31 @@ func flattenAzureRmContainerServiceLinuxProfile(profile containerservice.LinuxPr
 	return profiles
 }
 
+func flattenAzureRmContainerServiceWindowsProfile(d *schema.ResourceData, profile *containerservice.WindowsProfile) *schema.Set {
+	if profile == nil || profile.AdminUsername == nil {
+		return nil
+	}
+
+	profiles := &schema.Set{
+		F: resourceAzureRMContainerServiceWindowsProfilesHash,
+	}
+
+	values := map[string]interface{}{}
+	values["admin_username"] = *profile.AdminUsername
+
+	// the password isn't returned from the API, so we pull it from the existing state
+	for _, v := range d.Get("windows_profile").(*schema.Set).List() {
+		existing := v.(map[string]interface{})
+		if existing["admin_username"].(string) == *profile.AdminUsername {
+			values["admin_password"] = existing["admin_password"].(string)
+		}
+	}
+
+	profiles.Add(values)
+
+	return profiles
+}
+
 func flattenAzureRmContainerServiceAgentPoolProfiles(profiles *[]containerservice.AgentPoolProfile) *schema.Set {
 	agentPoolProfiles := &schema.Set{
 		F: resourceAzureRMContainerServiceAgentPoolProfilesHash,
@@ -381,13 +523,24 @@ func flattenAzureRmContainerServiceAgentPoolProfiles(profiles *[]containerservic
 		agentPoolProfile["fqdn"] = *profile.Fqdn
 		agentPoolProfile["name"] = *profile.Name
 		agentPoolProfile["vm_size"] = string(profile.VMSize)
+		if profile.OsDiskSizeGB != nil {
+			agentPoolProfile["os_disk_size_gb"] = int(*profile.OsDiskSizeGB)
+		}
+		if profile.VnetSubnetID != nil {
+			agentPoolProfile["vnet_subnet_id"] = *profile.VnetSubnetID
+		}
+		// the API omits the OS Type for Linux Agent Pools
+		agentPoolProfile["os_type"] = string(containerservice.Linux)
+		if profile.OsType != "" {
+			agentPoolProfile["os_type"] = string(profile.OsType)
+		}
 		agentPoolProfiles.Add(agentPoolProfile)
 	}
 
 	return agentPoolProfiles
 }
 
-func flattenAzureRmContainerServiceServicePrincipalProfile(profile *containerservice.ServicePrincipalProfile) *schema.Set {
+func flattenAzureRmContainerServiceServicePrincipalProfile(d *schema.ResourceData, profile *containerservice.ServicePrincipalProfile) *schema.Set {
 
 	if profile == nil {
 		return nil
@@ -402,6 +555,15 @@ func flattenAzureRmContainerServiceServicePrincipalProfile(profile *containerser
 	values["client_id"] = *profile.ClientID
 	if profile.Secret != nil {
 		values["client_secret"] = *profile.Secret
+	} else {
+		// the secret isn't returned from the API, so we pull it from the existing state
+		// (which won't be present when importing, in which case a diff will be shown)
+		for _, v := range d.Get("service_principal").(*schema.Set).List() {
+			existing := v.(map[string]interface{})
+			if existing["client_id"].(string) == *profile.ClientID {
+				values["client_secret"] = existing["client_secret"].(string)
+			}
+		}
 	}
 
 	servicePrincipalProfiles.Add(values)
@@ -410,6 +572,10 @@ func flattenAzureRmContainerServiceServicePrincipalProfile(profile *containerser
 }
 
 func flattenAzureRmContainerServiceDiagnosticsProfile(profile *containerservice.DiagnosticsProfile) *schema.Set {
+	if profile == nil || profile.VMDiagnostics == nil {
+		return nil
+	}
+
 	diagnosticProfiles := &schema.Set{
 		F: resourceAzureRMContainerServiceDiagnosticProfilesHash,
 	}
@@ -427,13 +593,15 @@ func flattenAzureRmContainerServiceDiagnosticsProfile(profile *containerservice.
 
 func expandAzureRmContainerServiceDiagnostics(d *schema.ResourceData) containerservice.DiagnosticsProfile {
 	configs := d.Get("diagnostics_profile").(*schema.Set).List()
-	profile := containerservice.DiagnosticsProfile{}
-
-	data := configs[0].(map[string]interface{})
 
-	enabled := data["enabled"].(bool)
+	// VM Diagnostics are disabled when the block isn't specified
+	enabled := false
+	if len(configs) > 0 {
+		data := configs[0].(map[string]interface{})
+		enabled = data["enabled"].(bool)
+	}
 
-	profile = containerservice.DiagnosticsProfile{
+	profile := containerservice.DiagnosticsProfile{
 		VMDiagnostics: &containerservice.VMDiagnostics{
 			Enabled: &enabled,
 		},
@@ -470,6 +638,25 @@ func expandAzureRmContainerServiceLinuxProfile(d *schema.ResourceData) container
 	return profile
 }
 
+func expandAzureRmContainerServiceWindowsProfile(d *schema.ResourceData) *containerservice.WindowsProfile {
+	profiles := d.Get("windows_profile").(*schema.Set).List()
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	config := profiles[0].(map[string]interface{})
+
+	adminUsername := config["admin_username"].(string)
+	adminPassword := config["admin_password"].(string)
+
+	profile := containerservice.WindowsProfile{
+		AdminUsername: &adminUsername,
+		AdminPassword: &adminPassword,
+	}
+
+	return &profile
+}
+
 func expandAzureRmContainerServiceMasterProfile(d *schema.ResourceData) containerservice.MasterProfile {
 	configs := d.Get("master_profile").(*schema.Set).List()
 	config := configs[0].(map[string]interface{})
@@ -509,22 +696,39 @@ func expandAzureRmContainerServiceServicePrincipal(d *schema.ResourceData) *cont
 
 func expandAzureRmContainerServiceAgentProfiles(d *schema.ResourceData) []containerservice.AgentPoolProfile {
 	configs := d.Get("agent_pool_profile").(*schema.Set).List()
-	config := configs[0].(map[string]interface{})
 	profiles := make([]containerservice.AgentPoolProfile, 0, len(configs))
 
-	name := config["name"].(string)
-	count := int32(config["count"].(int))
-	dnsPrefix := config["dns_prefix"].(string)
-	vmSize := config["vm_size"].(string)
+	for _, v := range configs {
+		config := v.(map[string]interface{})
 
-	profile := containerservice.AgentPoolProfile{
-		Name:      &name,
-		Count:     &count,
-		VMSize:    containerservice.VMSizeTypes(vmSize),
-		DNSPrefix: &dnsPrefix,
-	}
+		name := config["name"].(string)
+		count := int32(config["count"].(int))
+		dnsPrefix := config["dns_prefix"].(string)
+		vmSize := config["vm_size"].(string)
+
+		profile := containerservice.AgentPoolProfile{
+			Name:      &name,
+			Count:     &count,
+			VMSize:    containerservice.VMSizeTypes(vmSize),
+			DNSPrefix: &dnsPrefix,
+		}
+
+		if osDiskSizeGB := int32(config["os_disk_size_gb"].(int)); osDiskSizeGB > 0 {
+			profile.OsDiskSizeGB = &osDiskSizeGB
+		}
 
-	profiles = append(profiles, profile)
+		if vnetSubnetID := config["vnet_subnet_id"].(string); vnetSubnetID != "" {
+			profile.VnetSubnetID = &vnetSubnetID
+		}
+
+		if strings.EqualFold(config["os_type"].(string), string(containerservice.Windows)) {
+			profile.OsType = containerservice.Windows
+		} else {
+			profile.OsType = containerservice.Linux
+		}
+
+		profiles = append(profiles, profile)
+	}
 
 	return profiles
 }
@@ -572,6 +776,16 @@ func resourceAzureRMContainerServiceLinuxProfilesSSHKeysHash(v interface{}) int
 	return hashcode.String(buf.String())
 }
 
+func resourceAzureRMContainerServiceWindowsProfilesHash(v interface{}) int {
+	var buf bytes.Buffer
+
+	if m, ok := v.(map[string]interface{}); ok {
+		buf.WriteString(fmt.Sprintf("%s-", m["admin_username"].(string)))
+	}
+
+	return hashcode.String(buf.String())
+}
+
 func resourceAzureRMContainerServiceAgentPoolProfilesHash(v interface{}) int {
 	var buf bytes.Buffer
 
@@ -580,6 +794,16 @@ func resourceAzureRMContainerServiceAgentPoolProfilesHash(v interface{}) int {
 		buf.WriteString(fmt.Sprintf("%s-", m["dns_prefix"].(string)))
 		buf.WriteString(fmt.Sprintf("%s-", m["name"].(string)))
 		buf.WriteString(fmt.Sprintf("%s-", m["vm_size"].(string)))
+
+		// only set Subnet IDs are hashed, so existing Agent Pool Profiles keep their hash
+		if v, ok := m["vnet_subnet_id"]; ok && v.(string) != "" {
+			buf.WriteString(fmt.Sprintf("%s-", strings.ToLower(v.(string))))
+		}
+
+		// only Windows is hashed, so existing (Linux) Agent Pool Profiles keep their hash
+		if v, ok := m["os_type"]; ok && strings.EqualFold(v.(string), string(containerservice.Windows)) {
+			buf.WriteString(fmt.Sprintf("%s-", string(containerservice.Windows)))
+		}
 	}
 
 	return hashcode.String(buf.String())
@@ -619,6 +843,34 @@ func validateArmContainerServiceOrchestrationPlatform(v interface{}, k string) (
 	return
 }
 
+var containerServiceOrchestrationVersionRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+
+func validateArmContainerServiceOrchestrationVersion(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if !containerServiceOrchestrationVersionRegex.MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q must be a version in the format `major.minor` or `major.minor.patch` (e.g. `1.8.11`), got %q", k, value))
+	}
+
+	return
+}
+
+var containerServiceDNSPrefixRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+func validateArmContainerServiceDNSPrefix(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if len(value) < 1 || len(value) > 45 {
+		errors = append(errors, fmt.Errorf("%q must be between 1 and 45 characters in length", k))
+	}
+
+	if !containerServiceDNSPrefixRegex.MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q may only contain lowercase alphanumeric characters and hyphens, and must start and end with an alphanumeric character", k))
+	}
+
+	return
+}
+
 func validateArmContainerServiceMasterProfileCount(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(int)
 	capacities := map[int]bool{
@@ -640,3 +892,32 @@ func validateArmContainerServiceAgentPoolProfileCount(v interface{}, k string) (
 	}
 	return
 }
+
+var containerServiceWindowsAdminPasswordClasses = []*regexp.Regexp{
+	regexp.MustCompile(`[a-z]`),
+	regexp.MustCompile(`[A-Z]`),
+	regexp.MustCompile(`[0-9]`),
+	regexp.MustCompile(`[^a-zA-Z0-9]`),
+}
+
+func validateArmContainerServiceWindowsAdminPassword(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if len(value) < 12 || len(value) > 123 {
+		errors = append(errors, fmt.Errorf("%q must be between 12 and 123 characters in length", k))
+	}
+
+	// Azure requires 3 of the 4 character classes to be present
+	matches := 0
+	for _, class := range containerServiceWindowsAdminPasswordClasses {
+		if class.MatchString(value) {
+			matches++
+		}
+	}
+
+	if matches < 3 {
+		errors = append(errors, fmt.Errorf("%q must contain at least 3 of: a lowercase character, an uppercase character, a digit and a special character", k))
+	}
+
+	return
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -31,6 +33,29 @@ func TestAccAzureRMContainerService_orchestrationPlatformValidation(t *testing.T
 	}
 }
 
+func TestAccAzureRMContainerService_orchestrationVersionValidation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "1.8", ErrCount: 0},
+		{Value: "1.8.11", ErrCount: 0},
+		{Value: "", ErrCount: 1},
+		{Value: "1", ErrCount: 1},
+		{Value: "v1.8.11", ErrCount: 1},
+		{Value: "1.8.11-beta", ErrCount: 1},
+		{Value: "latest", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmContainerServiceOrchestrationVersion(tc.Value, "orchestration_version")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the Azure RM Container Service Orchestration Version %q to trigger %d validation errors but got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
 func TestAccAzureRMContainerService_masterProfileCountValidation(t *testing.T) {
 	cases := []struct {
 		Value    int
@@ -76,6 +101,117 @@ func TestAccAzureRMContainerService_agentProfilePoolCountValidation(t *testing.T
 	}
 }
 
+func TestAccAzureRMContainerService_dnsPrefixValidation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "", ErrCount: 2},
+		{Value: "a", ErrCount: 0},
+		{Value: "acctestmaster1", ErrCount: 0},
+		{Value: "acc-test-agent-1", ErrCount: 0},
+		{Value: "-acctestmaster", ErrCount: 1},
+		{Value: "acctestmaster-", ErrCount: 1},
+		{Value: "AccTestMaster", ErrCount: 1},
+		{Value: "acc_test_master", ErrCount: 1},
+		{Value: strings.Repeat("a", 45), ErrCount: 0},
+		{Value: strings.Repeat("a", 46), ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmContainerServiceDNSPrefix(tc.Value, "dns_prefix")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the Azure RM Container Service DNS Prefix %q to trigger %d validation errors but got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestAccAzureRMContainerService_windowsAdminPasswordValidation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "", ErrCount: 2},
+		{Value: "Pa55w0rd!", ErrCount: 1},
+		{Value: "Passw0rd1234", ErrCount: 0},
+		{Value: "password!1234", ErrCount: 0},
+		{Value: "PASSWORD!1234", ErrCount: 0},
+		{Value: "PASSWORD!ABCD", ErrCount: 1},
+		{Value: "passwordabcd", ErrCount: 1},
+		{Value: "password1234", ErrCount: 1},
+		{Value: "Pa5" + strings.Repeat("a", 120), ErrCount: 0},
+		{Value: "Pa5" + strings.Repeat("a", 121), ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmContainerServiceWindowsAdminPassword(tc.Value, "admin_password")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the Azure RM Container Service Windows Admin Password %q to trigger %d validation errors but got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestAccAzureRMContainerService_agentPoolProfilesHash(t *testing.T) {
+	profile := func(vnetSubnetID string) map[string]interface{} {
+		return map[string]interface{}{
+			"count":          1,
+			"dns_prefix":     "acctestagent",
+			"name":           "default",
+			"vm_size":        "Standard_F2",
+			"vnet_subnet_id": vnetSubnetID,
+		}
+	}
+
+	first := resourceAzureRMContainerServiceAgentPoolProfilesHash(profile("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1"))
+	second := resourceAzureRMContainerServiceAgentPoolProfilesHash(profile("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet2"))
+	if first == second {
+		t.Fatalf("Expected changing the `vnet_subnet_id` to change the Agent Pool Profile hash")
+	}
+
+	casing := resourceAzureRMContainerServiceAgentPoolProfilesHash(profile("/subscriptions/00000000-0000-0000-0000-000000000000/resourcegroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1"))
+	if first != casing {
+		t.Fatalf("Expected the Agent Pool Profile hash to ignore the casing of the `vnet_subnet_id`")
+	}
+
+	omitted := resourceAzureRMContainerServiceAgentPoolProfilesHash(profile(""))
+	legacy := resourceAzureRMContainerServiceAgentPoolProfilesHash(map[string]interface{}{
+		"count":      1,
+		"dns_prefix": "acctestagent",
+		"name":       "default",
+		"vm_size":    "Standard_F2",
+	})
+	if omitted != legacy {
+		t.Fatalf("Expected an Agent Pool Profile without a `vnet_subnet_id` to keep its existing hash")
+	}
+
+	linux := profile("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1")
+	linux["os_type"] = "Linux"
+	if resourceAzureRMContainerServiceAgentPoolProfilesHash(linux) != first {
+		t.Fatalf("Expected a Linux `os_type` not to change the Agent Pool Profile hash")
+	}
+
+	windows := profile("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1")
+	windows["os_type"] = "Windows"
+	if resourceAzureRMContainerServiceAgentPoolProfilesHash(windows) == first {
+		t.Fatalf("Expected a Windows `os_type` to change the Agent Pool Profile hash")
+	}
+}
+
+func TestAccAzureRMContainerService_diagnosticsProfileOmitted(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceArmContainerService().Schema, map[string]interface{}{})
+
+	profile := expandAzureRmContainerServiceDiagnostics(d)
+	if profile.VMDiagnostics == nil || profile.VMDiagnostics.Enabled == nil {
+		t.Fatalf("Expected VM Diagnostics to be set when `diagnostics_profile` is omitted")
+	}
+
+	if *profile.VMDiagnostics.Enabled {
+		t.Fatalf("Expected VM Diagnostics to be disabled when `diagnostics_profile` is omitted")
+	}
+}
+
 func TestAccAzureRMContainerService_dcosBasic(t *testing.T) {
 	ri := acctest.RandInt()
 	config := testAccAzureRMContainerService_dcosBasic(ri, testLocation())
@@ -116,6 +252,28 @@ func TestAccAzureRMContainerService_kubernetesBasic(t *testing.T) {
 	})
 }
 
+func TestAccAzureRMContainerService_kubernetesOrchestrationVersion(t *testing.T) {
+	ri := acctest.RandInt()
+	clientId := os.Getenv("ARM_CLIENT_ID")
+	clientSecret := os.Getenv("ARM_CLIENT_SECRET")
+	config := testAccAzureRMContainerService_kubernetesOrchestrationVersion(ri, clientId, clientSecret, "1.8.11", testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerServiceExists("azurerm_container_service.test"),
+					resource.TestCheckResourceAttr("azurerm_container_service.test", "orchestration_version", "1.8.11"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccAzureRMContainerService_kubernetesComplete(t *testing.T) {
 	ri := acctest.RandInt()
 	clientId := os.Getenv("ARM_CLIENT_ID")
@@ -137,6 +295,49 @@ func TestAccAzureRMContainerService_kubernetesComplete(t *testing.T) {
 	})
 }
 
+func TestAccAzureRMContainerService_kubernetesOsDiskSize(t *testing.T) {
+	ri := acctest.RandInt()
+	clientId := os.Getenv("ARM_CLIENT_ID")
+	clientSecret := os.Getenv("ARM_CLIENT_SECRET")
+	config := testAccAzureRMContainerService_kubernetesOsDiskSize(ri, clientId, clientSecret, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerServiceExists("azurerm_container_service.test"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMContainerService_kubernetesMultipleAgentPools(t *testing.T) {
+	ri := acctest.RandInt()
+	clientId := os.Getenv("ARM_CLIENT_ID")
+	clientSecret := os.Getenv("ARM_CLIENT_SECRET")
+	config := testAccAzureRMContainerService_kubernetesMultipleAgentPools(ri, clientId, clientSecret, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerServiceExists("azurerm_container_service.test"),
+					resource.TestCheckResourceAttr("azurerm_container_service.test", "agent_pool_profile.#", "2"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccAzureRMContainerService_swarmBasic(t *testing.T) {
 	ri := acctest.RandInt()
 	config := testAccAzureRMContainerService_swarmBasic(ri, testLocation())
@@ -156,6 +357,26 @@ func TestAccAzureRMContainerService_swarmBasic(t *testing.T) {
 	})
 }
 
+func TestAccAzureRMContainerService_swarmDiagnosticsProfileOmitted(t *testing.T) {
+	ri := acctest.RandInt()
+	config := testAccAzureRMContainerService_diagnosticsProfileOmitted(ri, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMContainerServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMContainerServiceExists("azurerm_container_service.test"),
+					resource.TestCheckResourceAttr("azurerm_container_service.test", "diagnostics_profile.#", "1"),
+				),
+			},
+		},
+	})
+}
+
 func testAccAzureRMContainerService_dcosBasic(rInt int, location string) string {
 	return fmt.Sprintf(`
 resource "azurerm_resource_group" "test" {
@@ -241,6 +462,52 @@ resource "azurerm_container_service" "test" {
 `, rInt, location, rInt, rInt, rInt, rInt, clientId, clientSecret)
 }
 
+func testAccAzureRMContainerService_kubernetesOrchestrationVersion(rInt int, clientId string, clientSecret string, version string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_container_service" "test" {
+  name                   = "acctestcontservice%d"
+  location               = "${azurerm_resource_group.test.location}"
+  resource_group_name    = "${azurerm_resource_group.test.name}"
+  orchestration_platform = "Kubernetes"
+  orchestration_version  = "%s"
+
+  master_profile {
+    count      = 1
+    dns_prefix = "acctestmaster%d"
+  }
+
+  linux_profile {
+    admin_username = "acctestuser%d"
+
+    ssh_key {
+      key_data = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQCqaZoyiz1qbdOQ8xEf6uEu1cCwYowo5FHtsBhqLoDnnp7KUTEBN+L2NxRIfQ781rxV6Iq5jSav6b2Q8z5KiseOlvKA/RF2wqU0UPYqQviQhLmW6THTpmrv/YkUCuzxDpsH7DUDhZcwySLKVVe0Qm3+5N2Ta6UYH3lsDf9R9wTP2K/+vAnflKebuypNlmocIvakFWoZda18FOmsOoIVXQ8HWFNCuw9ZCunMSN62QGamCe3dL5cXlkgHYv7ekJE15IA9aOJcM7e90oeTqo+7HTcWfdu0qQqPWY5ujyMw/llas8tsXY85LFqRnr3gJ02bAscjc477+X+j/gkpFoN1QEmt terraform@demo.tld"
+    }
+  }
+
+  agent_pool_profile {
+    name       = "default"
+    count      = 1
+    dns_prefix = "acctestagent%d"
+    vm_size    = "Standard_F2"
+  }
+
+  service_principal {
+    client_id     = "%s"
+    client_secret = "%s"
+  }
+
+  diagnostics_profile {
+    enabled = false
+  }
+}
+`, rInt, location, rInt, version, rInt, rInt, rInt, clientId, clientSecret)
+}
+
 func testAccAzureRMContainerService_kubernetesComplete(rInt int, clientId string, clientSecret string, location string) string {
 	return fmt.Sprintf(`
 resource "azurerm_resource_group" "test" {
@@ -290,6 +557,108 @@ resource "azurerm_container_service" "test" {
 `, rInt, location, rInt, rInt, rInt, rInt, clientId, clientSecret)
 }
 
+func testAccAzureRMContainerService_kubernetesOsDiskSize(rInt int, clientId string, clientSecret string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_container_service" "test" {
+  name                   = "acctestcontservice%d"
+  location               = "${azurerm_resource_group.test.location}"
+  resource_group_name    = "${azurerm_resource_group.test.name}"
+  orchestration_platform = "Kubernetes"
+
+  master_profile {
+    count      = 1
+    dns_prefix = "acctestmaster%d"
+  }
+
+  linux_profile {
+    admin_username = "acctestuser%d"
+
+    ssh_key {
+      key_data = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQCqaZoyiz1qbdOQ8xEf6uEu1cCwYowo5FHtsBhqLoDnnp7KUTEBN+L2NxRIfQ781rxV6Iq5jSav6b2Q8z5KiseOlvKA/RF2wqU0UPYqQviQhLmW6THTpmrv/YkUCuzxDpsH7DUDhZcwySLKVVe0Qm3+5N2Ta6UYH3lsDf9R9wTP2K/+vAnflKebuypNlmocIvakFWoZda18FOmsOoIVXQ8HWFNCuw9ZCunMSN62QGamCe3dL5cXlkgHYv7ekJE15IA9aOJcM7e90oeTqo+7HTcWfdu0qQqPWY5ujyMw/llas8tsXY85LFqRnr3gJ02bAscjc477+X+j/gkpFoN1QEmt terraform@demo.tld"
+    }
+  }
+
+  agent_pool_profile {
+    name            = "default"
+    count           = 1
+    dns_prefix      = "acctestagent%d"
+    vm_size         = "Standard_F2"
+    os_disk_size_gb = 50
+  }
+
+  service_principal {
+    client_id     = "%s"
+    client_secret = "%s"
+  }
+
+  diagnostics_profile {
+    enabled = false
+  }
+
+  tags {
+    you = "me"
+  }
+}
+`, rInt, location, rInt, rInt, rInt, rInt, clientId, clientSecret)
+}
+
+func testAccAzureRMContainerService_kubernetesMultipleAgentPools(rInt int, clientId string, clientSecret string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_container_service" "test" {
+  name                   = "acctestcontservice%d"
+  location               = "${azurerm_resource_group.test.location}"
+  resource_group_name    = "${azurerm_resource_group.test.name}"
+  orchestration_platform = "Kubernetes"
+
+  master_profile {
+    count      = 1
+    dns_prefix = "acctestmaster%d"
+  }
+
+  linux_profile {
+    admin_username = "acctestuser%d"
+
+    ssh_key {
+      key_data = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQCqaZoyiz1qbdOQ8xEf6uEu1cCwYowo5FHtsBhqLoDnnp7KUTEBN+L2NxRIfQ781rxV6Iq5jSav6b2Q8z5KiseOlvKA/RF2wqU0UPYqQviQhLmW6THTpmrv/YkUCuzxDpsH7DUDhZcwySLKVVe0Qm3+5N2Ta6UYH3lsDf9R9wTP2K/+vAnflKebuypNlmocIvakFWoZda18FOmsOoIVXQ8HWFNCuw9ZCunMSN62QGamCe3dL5cXlkgHYv7ekJE15IA9aOJcM7e90oeTqo+7HTcWfdu0qQqPWY5ujyMw/llas8tsXY85LFqRnr3gJ02bAscjc477+X+j/gkpFoN1QEmt terraform@demo.tld"
+    }
+  }
+
+  agent_pool_profile {
+    name       = "default"
+    count      = 1
+    dns_prefix = "acctestagent%d"
+    vm_size    = "Standard_F2"
+  }
+
+  agent_pool_profile {
+    name       = "second"
+    count      = 2
+    dns_prefix = "acctestagentsecond%d"
+    vm_size    = "Standard_F2"
+  }
+
+  service_principal {
+    client_id     = "%s"
+    client_secret = "%s"
+  }
+
+  diagnostics_profile {
+    enabled = false
+  }
+}
+`, rInt, location, rInt, rInt, rInt, rInt, rInt, clientId, clientSecret)
+}
+
 func testAccAzureRMContainerService_swarmBasic(rInt int, location string) string {
 	return fmt.Sprintf(`
 resource "azurerm_resource_group" "test" {
@@ -330,6 +699,42 @@ resource "azurerm_container_service" "test" {
 `, rInt, location, rInt, rInt, rInt, rInt)
 }
 
+func testAccAzureRMContainerService_diagnosticsProfileOmitted(rInt int, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_container_service" "test" {
+  name                   = "acctestcontservice%d"
+  location               = "${azurerm_resource_group.test.location}"
+  resource_group_name    = "${azurerm_resource_group.test.name}"
+  orchestration_platform = "Swarm"
+
+  master_profile {
+    count      = 1
+    dns_prefix = "acctestmaster%d"
+  }
+
+  linux_profile {
+    admin_username = "acctestuser%d"
+
+    ssh_key {
+      key_data = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQCqaZoyiz1qbdOQ8xEf6uEu1cCwYowo5FHtsBhqLoDnnp7KUTEBN+L2NxRIfQ781rxV6Iq5jSav6b2Q8z5KiseOlvKA/RF2wqU0UPYqQviQhLmW6THTpmrv/YkUCuzxDpsH7DUDhZcwySLKVVe0Qm3+5N2Ta6UYH3lsDf9R9wTP2K/+vAnflKebuypNlmocIvakFWoZda18FOmsOoIVXQ8HWFNCuw9ZCunMSN62QGamCe3dL5cXlkgHYv7ekJE15IA9aOJcM7e90oeTqo+7HTcWfdu0qQqPWY5ujyMw/llas8tsXY85LFqRnr3gJ02bAscjc477+X+j/gkpFoN1QEmt terraform@demo.tld"
+    }
+  }
+
+  agent_pool_profile {
+    name       = "default"
+    count      = 1
+    dns_prefix = "acctestagent%d"
+    vm_size    = "Standard_F2"
+  }
+}
+`, rInt, location, rInt, rInt, rInt, rInt)
+}
+
 func testCheckAzureRMContainerServiceExists(name string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		// Ensure we have enough information in state to look up in API